@@ -0,0 +1,409 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Kasita-Inc/gadget/database/qb"
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+const (
+	// defaultMaxPacket is used when the max_allowed_packet of the server cannot be determined (the MySQL default)
+	defaultMaxPacket = 4 << 20
+	// maxParameters is the number of placeholders MySQL and PostgreSQL allow in a single statement
+	maxParameters = 65535
+	// sqliteMaxParameters is the number of placeholders allowed by older SQLite builds
+	sqliteMaxParameters = 999
+	// statementOverhead estimates the size of an insert statement without its values
+	statementOverhead = 1024
+	// valueOverhead estimates the size of the placeholder and encoding of a single value
+	valueOverhead = 8
+	// defaultValueSize estimates the size of values that are not strings or bytes
+	defaultValueSize = 16
+)
+
+// BatchOptions control how CreateMany and UpsertMany write records
+type BatchOptions struct {
+	// SkipRead does not read the records back from the database after they are written
+	SkipRead bool
+	// MaxPacket is the maximum size in bytes of a single statement, defaults to the max_allowed_packet of the server
+	MaxPacket int
+}
+
+// batchChunk is a range of records [start, end) written in a single statement
+type batchChunk struct {
+	start int
+	end   int
+}
+
+// CreateMany initializes the Records and inserts them into the Database using multi-row inserts
+func (db *Database) CreateMany(objs []Record, options *BatchOptions) errors.TracerError {
+	return db.CreateManyContext(context.Background(), objs, options)
+}
+
+// CreateManyContext initializes the Records and inserts them into the Database using multi-row inserts, the inserts
+// are cancelled with the context. The Records that were inserted are committed before the Records that could not be
+// inserted are reported in a BatchError.
+func (db *Database) CreateManyContext(ctx context.Context, objs []Record, options *BatchOptions) errors.TracerError {
+	return db.inBatchTx(ctx, func(tx *sqlx.Tx) errors.TracerError {
+		return db.CreateManyTxContext(ctx, objs, tx, options)
+	})
+}
+
+// CreateManyTx initializes the Records and inserts them into the Database using multi-row inserts in a transaction.
+// Records that could not be inserted are reported by index in a BatchError.
+func (db *Database) CreateManyTx(objs []Record, tx *sqlx.Tx, options *BatchOptions) errors.TracerError {
+	return db.CreateManyTxContext(context.Background(), objs, tx, options)
+}
+
+// CreateManyTxContext initializes the Records and inserts them into the Database using multi-row inserts in a
+// transaction. The BeforeCreator and Validator interfaces of every Record are called before anything is inserted and
+// an error from either stops the batch. The AfterCreator interface is called for each Record that was inserted, after
+// it has been read back unless SkipRead is set. Records that could not be inserted are reported by index in a
+// BatchError.
+func (db *Database) CreateManyTxContext(ctx context.Context, objs []Record, tx *sqlx.Tx,
+	options *BatchOptions) errors.TracerError {
+	if len(objs) == 0 {
+		return nil
+	}
+	for _, obj := range objs {
+		obj.Initialize()
+	}
+	meta := objs[0].Meta()
+	return db.writeMany(ctx, objs, tx, options, appendIfMissing(meta.WriteColumns(), meta.PrimaryKey()), nil)
+}
+
+// UpsertMany inserts or updates the Records in the Database using multi-row inserts
+func (db *Database) UpsertMany(objs []Record, options *BatchOptions) errors.TracerError {
+	return db.UpsertManyContext(context.Background(), objs, options)
+}
+
+// UpsertManyContext inserts or updates the Records in the Database using multi-row inserts, the writes are cancelled
+// with the context. The Records that were written are committed before the Records that could not be written are
+// reported in a BatchError.
+func (db *Database) UpsertManyContext(ctx context.Context, objs []Record, options *BatchOptions) errors.TracerError {
+	return db.inBatchTx(ctx, func(tx *sqlx.Tx) errors.TracerError {
+		return db.UpsertManyTxContext(ctx, objs, tx, options)
+	})
+}
+
+// UpsertManyTx inserts or updates the Records in the Database using multi-row inserts in a transaction.
+// Records that could not be written are reported by index in a BatchError.
+func (db *Database) UpsertManyTx(objs []Record, tx *sqlx.Tx, options *BatchOptions) errors.TracerError {
+	return db.UpsertManyTxContext(context.Background(), objs, tx, options)
+}
+
+// UpsertManyTxContext inserts or updates the Records in the Database using multi-row inserts in a transaction. The
+// hooks of the Records are called the same as CreateManyTxContext. Records that could not be written are reported by
+// index in a BatchError.
+func (db *Database) UpsertManyTxContext(ctx context.Context, objs []Record, tx *sqlx.Tx,
+	options *BatchOptions) errors.TracerError {
+	if len(objs) == 0 {
+		return nil
+	}
+	insertCols, updateCols := upsertColumns(objs[0].Meta())
+	return db.writeMany(ctx, objs, tx, options, insertCols, updateCols)
+}
+
+// inBatchTx executes fn in a transaction that is committed when fn returns a BatchError, so that the rows that were
+// written are kept, and returns the BatchError after the commit
+func (db *Database) inBatchTx(ctx context.Context, fn func(tx *sqlx.Tx) errors.TracerError) errors.TracerError {
+	var batchErr errors.TracerError
+	err := db.InTxContext(ctx, func(tx *sqlx.Tx) error {
+		batchErr = nil
+		err := fn(tx)
+		if _, ok := err.(*BatchError); ok {
+			batchErr = err
+			return nil
+		}
+		return err
+	})
+	if nil != err {
+		return err
+	}
+	return batchErr
+}
+
+// writeMany inserts the records in chunks, updating the passed update columns when a record already exists
+func (db *Database) writeMany(ctx context.Context, objs []Record, tx *sqlx.Tx, options *BatchOptions,
+	columns []qb.TableField, updates []qb.TableField) errors.TracerError {
+	if nil == options {
+		options = &BatchOptions{}
+	}
+	meta := objs[0].Meta()
+	for _, obj := range objs {
+		if obj.Meta().GetName() != meta.GetName() {
+			return NewValidationError("all records in a batch must be for table %s", meta.GetName())
+		}
+	}
+	rows := make([][]interface{}, len(objs))
+	for i, obj := range objs {
+		if err := beforeCreate(obj, tx); nil != err {
+			return err
+		}
+		row, err := db.columnValues(obj, columns)
+		if nil != err {
+			return err
+		}
+		rows[i] = row
+	}
+	maxPacket := options.MaxPacket
+	if 0 >= maxPacket {
+		maxPacket = db.maxPacket(ctx, tx)
+	}
+	failed := make(map[int]errors.TracerError)
+	for _, chunk := range chunkRows(rows, maxPacket, db.maxParameters()) {
+		if err := db.insertChunk(ctx, tx, meta, columns, updates, rows, chunk, failed); nil != err {
+			return err
+		}
+		written := make([]Record, 0, chunk.end-chunk.start)
+		for i := chunk.start; i < chunk.end; i++ {
+			if _, ok := failed[i]; !ok {
+				written = append(written, objs[i])
+			}
+		}
+		if !options.SkipRead {
+			if err := db.readMany(ctx, tx, written); nil != err {
+				return err
+			}
+		}
+		for _, obj := range written {
+			if err := afterCreate(obj, tx); nil != err {
+				return err
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return NewBatchError(failed)
+	}
+	return nil
+}
+
+// insertChunk writes the rows of the chunk in a single statement. If the statement fails due to a row error the rows
+// are written one at a time so that the failures can be reported for each row, any other error stops the batch since
+// the transaction can no longer be used.
+func (db *Database) insertChunk(ctx context.Context, tx *sqlx.Tx, meta qb.Table, columns []qb.TableField,
+	updates []qb.TableField, rows [][]interface{}, chunk batchChunk,
+	failed map[int]errors.TracerError) errors.TracerError {
+	err := db.NestedTxContext(ctx, tx, func(tx *sqlx.Tx) error {
+		return db.insertRows(ctx, tx, meta, columns, updates, rows[chunk.start:chunk.end])
+	})
+	if nil == err {
+		return nil
+	}
+	if !isRowError(err) {
+		return err
+	}
+	for i := chunk.start; i < chunk.end; i++ {
+		row := rows[i : i+1]
+		err = db.NestedTxContext(ctx, tx, func(tx *sqlx.Tx) error {
+			return db.insertRows(ctx, tx, meta, columns, updates, row)
+		})
+		if nil == err {
+			continue
+		}
+		if !isRowError(err) {
+			return err
+		}
+		failed[i] = err
+	}
+	return nil
+}
+
+// isRowError is true for the constraint and validation errors caused by the values of a row, which are reported for
+// the row rather than stopping the batch
+func isRowError(err error) bool {
+	switch err.(type) {
+	case *DuplicateRecordError, *UniqueConstraintError, *InvalidForeignKeyError, *DataTooLongError, *ValidationError:
+		return true
+	}
+	return false
+}
+
+func (db *Database) insertRows(ctx context.Context, tx *sqlx.Tx, meta qb.Table, columns []qb.TableField,
+	updates []qb.TableField, rows [][]interface{}) errors.TracerError {
+	query := qb.Insert(columns...).Dialect(db.Dialect)
+	if len(updates) > 0 {
+		query.OnDuplicateInserted(updates...).OnConflict(meta.PrimaryKey())
+	}
+	for _, row := range rows {
+		query.Values(row...)
+	}
+	stmt, values, err := query.SQL()
+	if nil != err {
+		return errors.Wrap(err)
+	}
+	if _, err = db.execContext(ctx, tx, meta.GetName(), Insert, stmt, values...); nil != err {
+		return TranslateError(err, Insert, stmt, db.Logger)
+	}
+	return nil
+}
+
+// readMany populates the records from the database using a single query on their primary keys
+func (db *Database) readMany(ctx context.Context, tx *sqlx.Tx, objs []Record) errors.TracerError {
+	if len(objs) == 0 {
+		return nil
+	}
+	recordType := reflect.TypeOf(objs[0])
+	if reflect.Ptr != recordType.Kind() {
+		return NewNotAPointerError()
+	}
+	meta := objs[0].Meta()
+	byKey := make(map[interface{}]Record, len(objs))
+	keys := make([]interface{}, len(objs))
+	for i, obj := range objs {
+		keys[i] = obj.PrimaryKey().Value()
+		byKey[keys[i]] = obj
+	}
+	stmt, values, err := qb.Select(meta.AllColumns()).
+		From(meta).
+		Where(meta.PrimaryKey().In(keys...)).
+		Dialect(db.Dialect).
+		SQL(qb.NoLimit, 0)
+	if nil != err {
+		return errors.Wrap(err)
+	}
+
+	var columns []string
+	scanned := []reflect.Value{}
+	err = db.instrument(ctx, meta.GetName(), Select, stmt, values, func() (int64, error) {
+		rows, err := tx.QueryxContext(ctx, stmt, values...)
+		if nil != err {
+			return 0, err
+		}
+		defer rows.Close()
+		if columns, err = rows.Columns(); nil != err {
+			return 0, err
+		}
+		for rows.Next() {
+			record := reflect.New(recordType.Elem())
+			if err = rows.StructScan(record.Interface()); nil != err {
+				return 0, err
+			}
+			scanned = append(scanned, record)
+		}
+		return int64(len(scanned)), rows.Err()
+	})
+	if nil != err {
+		return TranslateError(err, Select, stmt, db.Logger)
+	}
+	for _, value := range scanned {
+		record, ok := value.Interface().(Record)
+		if !ok {
+			return NewValidationError("%s is not a Record", value.Type())
+		}
+		if target, ok := byKey[record.PrimaryKey().Value()]; ok {
+			// copy only the mapped columns so that any other state on the target is preserved
+			source := db.Mapper.FieldMap(value)
+			destination := db.Mapper.FieldMap(reflect.ValueOf(target))
+			for _, column := range columns {
+				if field, ok := destination[column]; ok && field.CanSet() {
+					field.Set(source[column])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// columnValues returns the values of the passed columns from the fields of the record
+func (db *Database) columnValues(obj Record, columns []qb.TableField) ([]interface{}, errors.TracerError) {
+	fields := db.Mapper.FieldMap(reflect.ValueOf(obj))
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		field, ok := fields[column.GetName()]
+		if !ok {
+			return nil, NewValidationError("%s has no field for column %s", reflect.TypeOf(obj), column.GetName())
+		}
+		values[i] = field.Interface()
+	}
+	return values, nil
+}
+
+// maxPacket returns the max_allowed_packet of the server, or the MySQL default for other dialects
+func (db *Database) maxPacket(ctx context.Context, tx *sqlx.Tx) int {
+	if nil != db.Dialect && qb.MySQL != db.Dialect {
+		return defaultMaxPacket
+	}
+	var size int
+	if err := db.getContext(ctx, tx, "", &size, "SELECT @@max_allowed_packet"); nil != err || size <= 0 {
+		return defaultMaxPacket
+	}
+	return size
+}
+
+// maxParameters returns the number of placeholders the dialect allows in a single statement
+func (db *Database) maxParameters() int {
+	if qb.SQLite == db.Dialect {
+		return sqliteMaxParameters
+	}
+	return maxParameters
+}
+
+// chunkRows splits the rows into chunks whose estimated statement size stays under maxPacket and that use no more
+// than maxParameters placeholders. A single row that exceeds the limits is still written on its own.
+func chunkRows(rows [][]interface{}, maxPacket int, maxParameters int) []batchChunk {
+	chunks := []batchChunk{}
+	current := batchChunk{}
+	size := statementOverhead
+	parameters := 0
+	for i, row := range rows {
+		rowSize := 0
+		for _, value := range row {
+			rowSize += valueSize(value) + valueOverhead
+		}
+		if i > current.start && (size+rowSize > maxPacket || parameters+len(row) > maxParameters) {
+			current.end = i
+			chunks = append(chunks, current)
+			current = batchChunk{start: i}
+			size = statementOverhead
+			parameters = 0
+		}
+		size += rowSize
+		parameters += len(row)
+	}
+	if len(rows) > current.start {
+		current.end = len(rows)
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// valueSize estimates the number of bytes used to send the value to the database
+func valueSize(value interface{}) int {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case driver.Valuer:
+		if inner, err := v.Value(); nil == err {
+			if _, ok := inner.(driver.Valuer); !ok {
+				return valueSize(inner)
+			}
+		}
+	}
+	return defaultValueSize
+}
+
+// upsertColumns returns the columns inserted and the columns updated when upserting a record of the passed table
+func upsertColumns(meta qb.Table) (insertCols []qb.TableField, updateCols []qb.TableField) {
+	insertCols = appendIfMissing(meta.ReadColumns(), meta.PrimaryKey())
+	updateCols = make([]qb.TableField, len(meta.WriteColumns()))
+	copy(updateCols, meta.WriteColumns())
+	createdOn := qb.TableField{Name: "created_on", Table: meta.GetName()}
+	if contains(meta.ReadColumns(), createdOn) {
+		updateCols = appendIfMissing(updateCols, createdOn)
+	}
+	updateOn := qb.TableField{Name: "updated_on", Table: meta.GetName()}
+	if contains(meta.ReadColumns(), updateOn) {
+		updateCols = appendIfMissing(updateCols, updateOn)
+	}
+	return insertCols, updateCols
+}
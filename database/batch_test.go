@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/generator"
+	"github.com/Kasita-Inc/gadget/log"
+)
+
+func TestChunkRows(t *testing.T) {
+	assert := assert.New(t)
+	rows := make([][]interface{}, 10)
+	for i := range rows {
+		rows[i] = []interface{}{generator.String(100), i}
+	}
+	assert.Equal([]batchChunk{{start: 0, end: 10}}, chunkRows(rows, defaultMaxPacket, maxParameters))
+	assert.Equal([]batchChunk{{start: 0, end: 4}, {start: 4, end: 8}, {start: 8, end: 10}},
+		chunkRows(rows, defaultMaxPacket, 8))
+	// each row is estimated at 132 bytes on top of the statement
+	assert.Equal([]batchChunk{{start: 0, end: 3}, {start: 3, end: 6}, {start: 6, end: 9}, {start: 9, end: 10}},
+		chunkRows(rows, statementOverhead+3*132, maxParameters))
+	// rows that are too large are still written on their own
+	assert.Len(chunkRows(rows, 1, maxParameters), 10)
+	assert.Empty(chunkRows([][]interface{}{}, defaultMaxPacket, maxParameters))
+}
+
+func TestCreateMany(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	records := make([]Record, 25)
+	for i := range records {
+		records[i] = &TestRecord{Name: generator.Name()}
+	}
+	assert.NoError(spec.DB.CreateMany(records, &BatchOptions{MaxPacket: statementOverhead + 1024}))
+	for _, record := range records {
+		actual := record.(*TestRecord)
+		assert.Equal("tst", actual.ID[:3])
+		assert.False(actual.CreatedOn.IsZero())
+
+		read := &TestRecord{}
+		assert.NoError(spec.DB.Read(read, record.PrimaryKey()))
+		assert.Equal(actual.Name, read.Name)
+	}
+}
+
+func TestCreateManyParameterLimit(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	// enough rows that SQLite needs more than one statement
+	columns := len(appendIfMissing(TestMeta.WriteColumns(), TestMeta.PrimaryKey()))
+	records := make([]Record, sqliteMaxParameters/columns+1)
+	for i := range records {
+		records[i] = &TestRecord{Name: generator.Name()}
+	}
+	assert.NoError(spec.DB.CreateMany(records, nil))
+	for _, record := range records {
+		assert.False(record.(*TestRecord).CreatedOn.IsZero())
+	}
+	assert.NoError(spec.DB.Read(&TestRecord{}, records[len(records)-1].PrimaryKey()))
+}
+
+func TestCreateManySkipRead(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	record := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.CreateMany([]Record{record}, &BatchOptions{SkipRead: true}))
+	assert.True(record.CreatedOn.IsZero())
+	assert.NoError(spec.DB.Read(record, record.PrimaryKey()))
+	assert.False(record.CreatedOn.IsZero())
+}
+
+func TestCreateManyDuplicate(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	existing := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.Create(existing))
+
+	records := []Record{
+		&TestRecord{Name: generator.Name()},
+		&TestRecord{Name: existing.Name},
+		&TestRecord{Name: generator.Name()},
+	}
+	tx := spec.DB.MustBegin()
+	err := spec.DB.CreateManyTx(records, tx, nil)
+	if assert.IsType(&BatchError{}, err) {
+		failed := err.(*BatchError).Errors
+		assert.Len(failed, 1)
+		assert.IsType(&UniqueConstraintError{}, failed[1])
+	}
+	assert.NoError(tx.Commit())
+
+	assert.NoError(spec.DB.Read(&TestRecord{}, records[0].PrimaryKey()))
+	assert.NoError(spec.DB.Read(&TestRecord{}, records[2].PrimaryKey()))
+	assert.IsType(&NotFoundError{}, spec.DB.Read(&TestRecord{}, records[1].PrimaryKey()))
+}
+
+func TestCreateManyCommitsWrittenRows(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	existing := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.Create(existing))
+
+	records := []Record{
+		&TestRecord{Name: generator.Name()},
+		&TestRecord{Name: existing.Name},
+		&TestRecord{Name: generator.Name()},
+	}
+	err := spec.DB.CreateMany(records, nil)
+	if assert.IsType(&BatchError{}, err) {
+		assert.Len(err.(*BatchError).Errors, 1)
+		assert.IsType(&UniqueConstraintError{}, err.(*BatchError).Errors[1])
+	}
+	assert.NoError(spec.DB.Read(&TestRecord{}, records[0].PrimaryKey()))
+	assert.NoError(spec.DB.Read(&TestRecord{}, records[2].PrimaryKey()))
+
+	created := &TestRecord{ID: generator.ID("tst"), Name: generator.Name()}
+	conflict := &TestRecord{ID: generator.ID("tst"), Name: existing.Name}
+	err = spec.DB.UpsertMany([]Record{created, conflict}, nil)
+	if assert.IsType(&BatchError{}, err) {
+		assert.Len(err.(*BatchError).Errors, 1)
+		assert.IsType(&UniqueConstraintError{}, err.(*BatchError).Errors[1])
+	}
+	assert.NoError(spec.DB.Read(&TestRecord{}, created.PrimaryKey()))
+	assert.IsType(&NotFoundError{}, spec.DB.Read(&TestRecord{}, conflict.PrimaryKey()))
+}
+
+func TestIsRowError(t *testing.T) {
+	assert := assert.New(t)
+	logger := log.NewStackLogger()
+	assert.True(isRowError(NewUniqueConstraintError(Insert, "", fmt.Errorf("foo"), logger)))
+	assert.True(isRowError(NewDuplicateRecordError(Insert, "", fmt.Errorf("foo"), logger)))
+	assert.True(isRowError(NewValidationError("foo")))
+	assert.False(isRowError(NewDeadlockError(Insert, "", fmt.Errorf("foo"), logger)))
+	assert.False(isRowError(NewLockWaitTimeoutError(Insert, "", fmt.Errorf("foo"), logger)))
+	assert.False(isRowError(NewDeadlineExceededError(Insert, "", fmt.Errorf("foo"), logger)))
+	assert.False(isRowError(NewSystemError(Insert, "", context.Canceled, logger)))
+}
+
+func TestCreateManyMixedTables(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	err := spec.DB.CreateMany([]Record{&TestRecord{Name: generator.Name()}, NewTestDuper()}, nil)
+	assert.IsType(&ValidationError{}, err)
+}
+
+func TestUpsertMany(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	existing := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.Create(existing))
+
+	updated := &TestRecord{ID: existing.ID, Name: generator.Name(), CreatedOn: existing.CreatedOn,
+		UpdatedOn: existing.UpdatedOn}
+	created := &TestRecord{ID: generator.ID("tst"), Name: generator.Name(), CreatedOn: existing.CreatedOn,
+		UpdatedOn: existing.UpdatedOn}
+	assert.NoError(spec.DB.UpsertMany([]Record{updated, created}, nil))
+	assert.Equal(existing.ID, updated.ID)
+
+	read := &TestRecord{}
+	assert.NoError(spec.DB.Read(read, existing.PrimaryKey()))
+	assert.Equal(updated.Name, read.Name)
+	assert.NoError(spec.DB.Read(read, created.PrimaryKey()))
+	assert.Equal(created.Name, read.Name)
+}
+
+func TestCreateManyHooks(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	records := []*hookedRecord{
+		{TestRecord: TestRecord{Name: generator.Name()}},
+		{TestRecord: TestRecord{Name: generator.Name()}},
+	}
+	assert.NoError(spec.DB.CreateMany([]Record{records[0], records[1]}, nil))
+	for _, record := range records {
+		assert.Equal([]string{"BeforeCreate", "Validate", "AfterCreate"}, record.calls)
+		assert.False(record.CreatedOn.IsZero())
+	}
+
+	records[0].calls = nil
+	records[0].Name = generator.Name()
+	assert.NoError(spec.DB.UpsertMany([]Record{records[0]}, nil))
+	assert.Equal([]string{"BeforeCreate", "Validate", "AfterCreate"}, records[0].calls)
+
+	// an invalid record stops the batch before anything is written
+	valid := &hookedRecord{TestRecord: TestRecord{Name: generator.Name()}}
+	invalid := &hookedRecord{TestRecord: TestRecord{Name: generator.Name()}, invalid: true}
+	assert.IsType(&ValidationError{}, spec.DB.CreateMany([]Record{valid, invalid}, nil))
+	assert.Equal([]string{"BeforeCreate", "Validate"}, valid.calls)
+	assert.IsType(&NotFoundError{}, spec.DB.Read(&TestRecord{}, valid.PrimaryKey()))
+}
+
+func TestCreateManyContext(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	record := &TestRecord{Name: generator.Name()}
+	assert.Error(spec.DB.CreateManyContext(ctx, []Record{record}, nil))
+	assert.Error(spec.DB.UpsertManyContext(ctx, []Record{record}, nil))
+	assert.IsType(&NotFoundError{}, spec.DB.Read(&TestRecord{}, record.PrimaryKey()))
+
+	assert.NoError(spec.DB.CreateManyContext(context.Background(), []Record{record}, nil))
+	assert.False(record.CreatedOn.IsZero())
+}
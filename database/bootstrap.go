@@ -6,6 +6,7 @@ import (
 	"html/template"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -54,7 +55,7 @@ type Bootstrapper interface {
 	DB() *Database
 	// TX returns the database transaction
 	TX() *sqlx.Tx
-	// FailOnError will rollback transaction and exit if an error is received
+	// FailOnError will rollback transaction and exit if an error is received, use Fixtures to handle the error instead
 	FailOnError(err error)
 }
 
@@ -157,13 +158,46 @@ func (bs *bootstrapper) WriteSQL(filename string, output []string) {
 }
 
 func (bs *bootstrapper) Insert(record Record) error {
-	if "" != record.PrimaryKey().Value() {
+	if hasPrimaryKey(record.PrimaryKey()) {
 		err := bs.db.UpsertTx(record, bs.TX())
 		return err
 	}
 	return bs.db.CreateTx(record, bs.TX())
 }
 
+// LoadFixtures inserts the rows of the fixture files in the transaction of the Bootstrapper
+func LoadFixtures(bs Bootstrapper, fixtures *Fixtures) error {
+	if err := fixtures.LoadTx(bs.TX()); nil != err {
+		return err
+	}
+	return nil
+}
+
+// InsertMany inserts the records into the database using multi-row inserts in the transaction of the Bootstrapper,
+// records that have a primary key are upserted
+func InsertMany(bs Bootstrapper, records []Record) error {
+	upserts := []Record{}
+	creates := []Record{}
+	for _, record := range records {
+		if hasPrimaryKey(record.PrimaryKey()) {
+			upserts = append(upserts, record)
+		} else {
+			creates = append(creates, record)
+		}
+	}
+	options := &BatchOptions{SkipRead: true}
+	if err := bs.DB().UpsertManyTx(upserts, bs.TX(), options); nil != err {
+		return err
+	}
+	return bs.DB().CreateManyTx(creates, bs.TX(), options)
+}
+
+// hasPrimaryKey is true when the primary key is not the zero value of its type
+func hasPrimaryKey(pk PrimaryKeyValue) bool {
+	value := reflect.ValueOf(pk.Value())
+	return value.IsValid() && !value.IsZero()
+}
+
 func (bs *bootstrapper) toSQLString(val interface{}) string {
 	if v, ok := val.(float64); ok {
 		return strconv.Itoa(int(v))
@@ -196,21 +230,11 @@ func (bs *bootstrapper) UpsertQuery(record Record) string {
 		columnValues[k] = v
 	}
 
-	insertCols := appendIfMissing(record.Meta().ReadColumns(), record.Meta().PrimaryKey())
+	insertCols, updateCols := upsertColumns(record.Meta())
 	insertVals := make([]interface{}, len(insertCols))
 	for i, col := range insertCols {
 		insertVals[i] = columnValues[col.GetName()]
 	}
-	updateCols := make([]qb.TableField, len(record.Meta().WriteColumns()))
-	copy(updateCols, record.Meta().WriteColumns())
-	createdOn := qb.TableField{Name: "created_on", Table: record.Meta().GetName()}
-	if contains(record.Meta().ReadColumns(), createdOn) {
-		updateCols = appendIfMissing(updateCols, createdOn)
-	}
-	updateOn := qb.TableField{Name: "updated_on", Table: record.Meta().GetName()}
-	if contains(record.Meta().ReadColumns(), updateOn) {
-		updateCols = appendIfMissing(updateCols, updateOn)
-	}
 	updateVals := make([]interface{}, len(updateCols))
 	for i, col := range updateCols {
 		updateVals[i] = columnValues[col.GetName()]
@@ -2,9 +2,11 @@ package database
 
 import (
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/Kasita-Inc/gadget/generator"
 	"github.com/Kasita-Inc/gadget/log"
 )
 
@@ -40,3 +42,47 @@ func TestToSQLString(t *testing.T) {
 	assert.Equal("'bob'", bs.toSQLString("bob"))
 	assert.Equal("true", bs.toSQLString(true))
 }
+
+func TestHasPrimaryKey(t *testing.T) {
+	assert := assert.New(t)
+	assert.False(hasPrimaryKey(NewPrimaryKey("")))
+	assert.True(hasPrimaryKey(NewPrimaryKey("tst_foo")))
+	assert.False(hasPrimaryKey(NewPrimaryKey(0)))
+	assert.True(hasPrimaryKey(NewPrimaryKey(42)))
+}
+
+func TestInsertMany(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	existing := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.Create(existing))
+	existing.Name = generator.Name()
+	created := &TestRecord{Name: generator.Name()}
+
+	bs := NewBootstrapper(spec.DB)
+	assert.NoError(InsertMany(bs, []Record{existing, created}))
+	assert.NoError(bs.TX().Commit())
+
+	actual := &TestRecord{}
+	assert.NoError(spec.DB.Read(actual, existing.PrimaryKey()))
+	assert.Equal(existing.Name, actual.Name)
+	assert.NoError(spec.DB.ReadOneWhere(actual, TestMeta.Name.Equal(created.Name)))
+}
+
+func TestLoadFixtures(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+	source := fstest.MapFS{
+		"test_archive.yaml": {Data: []byte("- id: arc-bootstrap\n  name: bootstrapped\n")},
+	}
+	fixtures, err := NewFixtures(spec.DB, source, ".", &TestArchiveRecord{})
+	assert.NoError(err)
+
+	// the rows are inserted in the transaction of the bootstrapper
+	bs := NewBootstrapper(spec.DB)
+	assert.NoError(LoadFixtures(bs, fixtures))
+	assert.NoError(spec.DB.ReadTx(&TestArchiveRecord{}, NewPrimaryKey("arc-bootstrap"), bs.TX()))
+	assert.NoError(bs.TX().Rollback())
+	assert.IsType(&NotFoundError{}, spec.DB.Read(&TestArchiveRecord{}, NewPrimaryKey("arc-bootstrap")))
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Kasita-Inc/gadget/database/qb"
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+// Tracked is a Record that takes a snapshot of its write columns each time it is read so that Update only writes the
+// columns that have changed since. Embed a ChangeTracker in the Record to implement the snapshot storage.
+type Tracked interface {
+	Record
+	// Snapshot returns the values of the write columns when the Record was last read, nil if it has not been read
+	Snapshot() map[string]interface{}
+	// SetSnapshot stores the values of the write columns
+	SetSnapshot(snapshot map[string]interface{})
+}
+
+// ChangeTracker stores the snapshot of a Tracked Record
+type ChangeTracker struct {
+	snapshot map[string]interface{}
+}
+
+// Snapshot returns the values of the write columns when the Record was last read, nil if it has not been read
+func (tracker *ChangeTracker) Snapshot() map[string]interface{} {
+	return tracker.snapshot
+}
+
+// SetSnapshot stores the values of the write columns
+func (tracker *ChangeTracker) SetSnapshot(snapshot map[string]interface{}) {
+	tracker.snapshot = snapshot
+}
+
+// UpdateFields writes only the passed columns of the Record to the database
+func (db *Database) UpdateFields(obj Record, fields ...qb.TableField) errors.TracerError {
+	return db.UpdateFieldsContext(context.Background(), obj, fields...)
+}
+
+// UpdateFieldsContext writes only the passed columns of the Record to the database, the update is cancelled with the
+// context
+func (db *Database) UpdateFieldsContext(ctx context.Context, obj Record, fields ...qb.TableField) errors.TracerError {
+	return db.InTxContext(ctx, func(tx *sqlx.Tx) error {
+		return db.UpdateFieldsTxContext(ctx, obj, tx, fields...)
+	})
+}
+
+// UpdateFieldsTx writes only the passed columns of the Record to the database using a transaction
+func (db *Database) UpdateFieldsTx(obj Record, tx *sqlx.Tx, fields ...qb.TableField) errors.TracerError {
+	return db.UpdateFieldsTxContext(context.Background(), obj, tx, fields...)
+}
+
+// UpdateFieldsTxContext writes only the passed columns of the Record to the database using a transaction. The columns
+// must be write columns of the Record's table. It behaves like UpdateTxContext otherwise.
+func (db *Database) UpdateFieldsTxContext(ctx context.Context, obj Record, tx *sqlx.Tx,
+	fields ...qb.TableField) errors.TracerError {
+	writeColumns := obj.Meta().WriteColumns()
+	columns := make([]qb.TableField, 0, len(fields))
+	for _, field := range fields {
+		column, ok := findColumn(writeColumns, field.GetName())
+		if !ok {
+			return NewValidationError("%s is not a write column of %s", field.GetName(), obj.Meta().GetName())
+		}
+		columns = appendIfMissing(columns, column)
+	}
+	if tracerErr := beforeUpdate(obj, tx); nil != tracerErr {
+		return tracerErr
+	}
+	return db.updateColumns(ctx, obj, tx, columns)
+}
+
+// DirtyColumns returns the write columns of a Tracked Record that have changed since it was read. All of the write
+// columns are returned when the Record is not Tracked or has not been read.
+func (db *Database) DirtyColumns(obj Record) ([]qb.TableField, errors.TracerError) {
+	writeColumns := obj.Meta().WriteColumns()
+	tracked, ok := obj.(Tracked)
+	if !ok || nil == tracked.Snapshot() {
+		return writeColumns, nil
+	}
+	current, err := db.columnSnapshot(obj, writeColumns)
+	if nil != err {
+		return nil, err
+	}
+	snapshot := tracked.Snapshot()
+	dirty := []qb.TableField{}
+	for _, column := range writeColumns {
+		previous, ok := snapshot[column.GetName()]
+		if !ok || !reflect.DeepEqual(previous, current[column.GetName()]) {
+			dirty = append(dirty, column)
+		}
+	}
+	return dirty, nil
+}
+
+// snapshot stores the write columns of the Tracked Records in dest, a pointer to a Record or to a slice of Records
+func (db *Database) snapshot(dest interface{}) {
+	value := reflect.ValueOf(dest)
+	if reflect.Ptr != value.Kind() || value.IsNil() {
+		return
+	}
+	if reflect.Slice != value.Elem().Kind() {
+		db.snapshotRecord(value)
+		return
+	}
+	slice := value.Elem()
+	for i := 0; i < slice.Len(); i++ {
+		element := slice.Index(i)
+		if reflect.Ptr != element.Kind() {
+			element = element.Addr()
+		}
+		db.snapshotRecord(element)
+	}
+}
+
+func (db *Database) snapshotRecord(value reflect.Value) {
+	if !value.IsValid() || (reflect.Ptr == value.Kind() && value.IsNil()) || !value.CanInterface() {
+		return
+	}
+	tracked, ok := value.Interface().(Tracked)
+	if !ok {
+		return
+	}
+	if snapshot, err := db.columnSnapshot(tracked, tracked.Meta().WriteColumns()); nil == err {
+		tracked.SetSnapshot(snapshot)
+	}
+}
+
+// columnSnapshot returns the driver values of the columns of the Record
+func (db *Database) columnSnapshot(obj Record, columns []qb.TableField) (map[string]interface{},
+	errors.TracerError) {
+	values, err := db.columnValues(obj, columns)
+	if nil != err {
+		return nil, err
+	}
+	snapshot := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		value, err := driver.DefaultParameterConverter.ConvertValue(values[i])
+		if nil != err {
+			// values that cannot be converted are compared as they are
+			value = values[i]
+		}
+		if bytes, ok := value.([]byte); ok {
+			// copy the bytes so that changes to the field are detected
+			value = string(bytes)
+		}
+		snapshot[column.GetName()] = value
+	}
+	return snapshot, nil
+}
+
+func findColumn(columns []qb.TableField, name string) (qb.TableField, bool) {
+	for _, column := range columns {
+		if column.GetName() == name {
+			return column, true
+		}
+	}
+	return qb.TableField{}, false
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/generator"
+)
+
+type trackedRecord struct {
+	TestRecord
+	ChangeTracker
+}
+
+func TestDirtyColumns(t *testing.T) {
+	assert := assert.New(t)
+	db := &Database{DB: sqlx.NewDb(nil, "mysql")}
+
+	record := &trackedRecord{TestRecord: TestRecord{Name: "first", Place: sql.NullString{String: "here", Valid: true}}}
+	dirty, err := db.DirtyColumns(record)
+	assert.NoError(err)
+	assert.Equal(TestMeta.WriteColumns(), dirty)
+
+	db.snapshot(record)
+	dirty, err = db.DirtyColumns(record)
+	assert.NoError(err)
+	assert.Empty(dirty)
+
+	record.Place.String = "there"
+	dirty, err = db.DirtyColumns(record)
+	assert.NoError(err)
+	assert.Equal(TestMeta.WriteColumns()[1:], dirty)
+
+	records := []trackedRecord{{TestRecord: TestRecord{Name: "second"}}}
+	db.snapshot(&records)
+	assert.Equal(map[string]interface{}{"name": "second", "place": nil}, records[0].Snapshot())
+
+	untracked := &TestRecord{Name: "third"}
+	db.snapshot(untracked)
+	dirty, err = db.DirtyColumns(untracked)
+	assert.NoError(err)
+	assert.Equal(TestMeta.WriteColumns(), dirty)
+}
+
+func TestUpdateFields(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+	hook := &recordingHook{}
+	spec.DB.AddHook(hook)
+
+	record := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.Create(record))
+
+	record.Name = generator.Name()
+	record.Place = sql.NullString{String: "ignored", Valid: true}
+	hook.after = nil
+	assert.NoError(spec.DB.UpdateFields(record, TestMeta.Name))
+	if assert.NotEmpty(hook.after) {
+		assert.Equal(SQLQueryType(Update), hook.after[0].Operation)
+		assert.NotContains(hook.after[0].SQL, "place")
+	}
+	actual := &TestRecord{}
+	assert.NoError(spec.DB.Read(actual, record.PrimaryKey()))
+	assert.Equal(record.Name, actual.Name)
+	assert.False(actual.Place.Valid)
+
+	assert.IsType(&ValidationError{}, spec.DB.UpdateFields(record, TestMeta.CreatedOn))
+}
+
+func TestTrackedUpdate(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+	hook := &recordingHook{}
+	spec.DB.AddHook(hook)
+
+	created := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.Create(created))
+	record := &trackedRecord{}
+	assert.NoError(spec.DB.Read(record, created.PrimaryKey()))
+
+	hook.after = nil
+	assert.NoError(spec.DB.Update(record))
+	for _, event := range hook.after {
+		assert.NotEqual(SQLQueryType(Update), event.Operation)
+	}
+
+	record.Place = sql.NullString{String: generator.Name(), Valid: true}
+	hook.after = nil
+	assert.NoError(spec.DB.Update(record))
+	if assert.NotEmpty(hook.after) {
+		assert.Equal(SQLQueryType(Update), hook.after[0].Operation)
+		assert.Contains(hook.after[0].SQL, "place")
+		assert.NotContains(hook.after[0].SQL, "name")
+	}
+	dirty, err := spec.DB.DirtyColumns(record)
+	assert.NoError(err)
+	assert.Empty(dirty)
+}
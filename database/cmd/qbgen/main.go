@@ -0,0 +1,43 @@
+// qbgen generates the qb.Table implementation of a struct from its db and qb struct tags. It is intended to be run
+// by go generate from the file that declares the struct:
+//
+//	//go:generate go run github.com/Kasita-Inc/gadget/database/cmd/qbgen -type=Widget
+//
+// which writes widget_table.go next to the source file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Kasita-Inc/gadget/database"
+	"github.com/Kasita-Inc/gadget/stringutil"
+)
+
+func main() {
+	typeName := flag.String("type", "", "name of the struct to generate the qb.Table for (required)")
+	table := flag.String("table", "", "name of the table, defaults to the struct name in snake case")
+	output := flag.String("output", "", "file to write, defaults to <type>_table.go next to the source file")
+	skipMeta := flag.Bool("skip-meta", false, "do not generate the Meta method of the record")
+	flag.Parse()
+
+	source := os.Getenv("GOFILE")
+	if flag.NArg() > 0 {
+		source = flag.Arg(0)
+	}
+	if "" == *typeName || "" == source {
+		fmt.Fprintln(os.Stderr, "usage: qbgen -type=<struct> [-table=<name>] [-output=<file>] [-skip-meta] [file.go]")
+		os.Exit(2)
+	}
+	if "" == *output {
+		*output = filepath.Join(filepath.Dir(source), stringutil.Underscore(*typeName)+"_table.go")
+	}
+
+	options := &database.GenerateOptions{Table: *table, SkipRecordMeta: *skipMeta}
+	if err := database.WriteTable(source, *typeName, *output, options); nil != err {
+		fmt.Fprintf(os.Stderr, "qbgen: %s\n", err)
+		os.Exit(1)
+	}
+}
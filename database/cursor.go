@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/Kasita-Inc/gadget/database/qb"
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+// CursorOptions provide limit and position capabilities for the cursor variants of List
+type CursorOptions struct {
+	Limit uint
+	// Cursor returned with the previous page, empty for the first page
+	Cursor string
+}
+
+// NewCursorOptions generates a CursorOptions
+func NewCursorOptions(limit uint, cursor string) *CursorOptions {
+	if 0 == limit {
+		limit = 1
+	} else if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	return &CursorOptions{
+		Limit:  limit,
+		Cursor: cursor,
+	}
+}
+
+// cursorToken is the decoded form of a cursor, the values are those of the sort by and primary key columns of the last
+// record on the previous page
+type cursorToken struct {
+	Table  string        `json:"t"`
+	Sort   string        `json:"s"`
+	Values []cursorValue `json:"v"`
+}
+
+// cursorValue retains the type of a value so that it is bound to the query as it was read
+type cursorValue struct {
+	Kind  string `json:"k"`
+	Value string `json:"v"`
+}
+
+const (
+	cursorInt    = "i"
+	cursorFloat  = "f"
+	cursorBool   = "b"
+	cursorString = "s"
+	cursorBytes  = "y"
+	cursorTime   = "t"
+)
+
+// ListCursor populates obj with the page of Records that follows the cursor, ordered by the SortBy of the Record and then
+// its primary key. The returned cursor retrieves the next page and is empty when there are no more Records.
+func (db *Database) ListCursor(def Record, obj interface{}, options *CursorOptions) (string, errors.TracerError) {
+	return db.ListWhereCursorContext(context.Background(), def, obj, nil, options)
+}
+
+// ListCursorContext populates obj with the page of Records that follows the cursor, the query is cancelled with the
+// context
+func (db *Database) ListCursorContext(ctx context.Context, def Record, obj interface{},
+	options *CursorOptions) (string, errors.TracerError) {
+	return db.ListWhereCursorContext(ctx, def, obj, nil, options)
+}
+
+// ListWhereCursor populates obj with the page of Records matching the condition that follows the cursor, ordered by the
+// SortBy of the Record and then its primary key. The returned cursor retrieves the next page and is empty when there
+// are no more Records. The same condition must be used for every page. Nil options read the first page of MaxLimit
+// Records.
+func (db *Database) ListWhereCursor(def Record, obj interface{}, condition *qb.ConditionExpression,
+	options *CursorOptions) (string, errors.TracerError) {
+	return db.ListWhereCursorContext(context.Background(), def, obj, condition, options)
+}
+
+// ListWhereCursorContext populates obj with the page of Records matching the condition that follows the cursor, the
+// query is cancelled with the context
+func (db *Database) ListWhereCursorContext(ctx context.Context, def Record, obj interface{},
+	condition *qb.ConditionExpression, options *CursorOptions) (string, errors.TracerError) {
+	target := reflect.ValueOf(obj)
+	if reflect.Ptr != target.Kind() || reflect.Slice != target.Elem().Kind() {
+		return "", NewNotAPointerError()
+	}
+	if nil == options {
+		options = &CursorOptions{Limit: MaxLimit}
+	}
+	options = NewCursorOptions(options.Limit, options.Cursor)
+	meta := def.Meta()
+	sortBy, direction := meta.SortBy()
+	columns := []qb.TableField{sortBy}
+	query := qb.Select(meta.AllColumns()).From(meta).OrderBy(sortBy, direction)
+	if sortBy != meta.PrimaryKey() {
+		columns = append(columns, meta.PrimaryKey())
+		query.OrderBy(meta.PrimaryKey(), direction)
+	}
+
+	if "" != options.Cursor {
+		values, err := decodeCursor(options.Cursor, meta.GetName(), sortBy.GetName(), len(columns))
+		if nil != err {
+			return "", err
+		}
+		// the condition of the caller is reused for the next page so it must not be modified
+		condition = qb.AllOf(condition, keysetCondition(columns, direction, values))
+	}
+
+	// read one extra record to determine if there is another page
+	stmt, values, err := query.Where(db.notDeleted(meta, condition)).Dialect(db.Dialect).SQL(options.Limit+1, 0)
+	if nil != err {
+		return "", errors.Wrap(err)
+	}
+	if err = db.selectContext(ctx, db.reader(), meta.GetName(), obj, stmt, values...); nil != err {
+		return "", TranslateError(err, Select, stmt, db.Logger)
+	}
+
+	page := target.Elem()
+	if uint(page.Len()) <= options.Limit {
+		return "", nil
+	}
+	page.Set(page.Slice(0, int(options.Limit)))
+	fields := db.Mapper.FieldMap(page.Index(page.Len() - 1))
+	last := make([]interface{}, len(columns))
+	for i, column := range columns {
+		field, ok := fields[column.GetName()]
+		if !ok {
+			return "", NewValidationError("%s has no field for column %s", page.Type().Elem(), column.GetName())
+		}
+		last[i] = field.Interface()
+	}
+	return encodeCursor(meta.GetName(), sortBy.GetName(), last)
+}
+
+// keysetCondition selects the rows that sort after the passed values of the columns
+func keysetCondition(columns []qb.TableField, direction qb.OrderDirection, values []interface{}) *qb.ConditionExpression {
+	comparison := qb.GreaterThan
+	if qb.Descending == direction {
+		comparison = qb.LessThan
+	}
+	// values are always bound so that strings are never interpreted as named parameters
+	last := len(columns) - 1
+	condition := qb.FieldComparison(columns[last], comparison, qb.Raw("?", values[last]))
+	for i := last - 1; i >= 0; i-- {
+		condition = qb.FieldComparison(columns[i], comparison, qb.Raw("?", values[i])).
+			Or(qb.FieldComparison(columns[i], qb.Equal, qb.Raw("?", values[i])).And(condition))
+	}
+	return condition
+}
+
+func encodeCursor(table string, sort string, values []interface{}) (string, errors.TracerError) {
+	token := cursorToken{Table: table, Sort: sort, Values: make([]cursorValue, len(values))}
+	for i, value := range values {
+		converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if nil != err {
+			return "", errors.Wrap(err)
+		}
+		switch v := converted.(type) {
+		case int64:
+			token.Values[i] = cursorValue{Kind: cursorInt, Value: strconv.FormatInt(v, 10)}
+		case float64:
+			token.Values[i] = cursorValue{Kind: cursorFloat, Value: strconv.FormatFloat(v, 'g', -1, 64)}
+		case bool:
+			token.Values[i] = cursorValue{Kind: cursorBool, Value: strconv.FormatBool(v)}
+		case string:
+			token.Values[i] = cursorValue{Kind: cursorString, Value: v}
+		case []byte:
+			token.Values[i] = cursorValue{Kind: cursorBytes, Value: base64.StdEncoding.EncodeToString(v)}
+		case time.Time:
+			token.Values[i] = cursorValue{Kind: cursorTime, Value: v.Format(time.RFC3339Nano)}
+		default:
+			return "", NewValidationError("cursor pagination requires non null sort by and primary key values")
+		}
+	}
+	data, err := json.Marshal(token)
+	if nil != err {
+		return "", errors.Wrap(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string, table string, sort string, count int) ([]interface{}, errors.TracerError) {
+	invalid := NewValidationError("invalid cursor")
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if nil != err {
+		return nil, invalid
+	}
+	token := cursorToken{}
+	if err = json.Unmarshal(data, &token); nil != err {
+		return nil, invalid
+	}
+	if token.Table != table || token.Sort != sort || len(token.Values) != count {
+		return nil, invalid
+	}
+	values := make([]interface{}, len(token.Values))
+	for i, value := range token.Values {
+		switch value.Kind {
+		case cursorInt:
+			values[i], err = strconv.ParseInt(value.Value, 10, 64)
+		case cursorFloat:
+			values[i], err = strconv.ParseFloat(value.Value, 64)
+		case cursorBool:
+			values[i], err = strconv.ParseBool(value.Value)
+		case cursorString:
+			values[i] = value.Value
+		case cursorBytes:
+			values[i], err = base64.StdEncoding.DecodeString(value.Value)
+		case cursorTime:
+			values[i], err = time.Parse(time.RFC3339Nano, value.Value)
+		default:
+			return nil, invalid
+		}
+		if nil != err {
+			return nil, invalid
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/database/qb"
+	"github.com/Kasita-Inc/gadget/generator"
+)
+
+func TestNewCursorOptions(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(&CursorOptions{Limit: 1}, NewCursorOptions(0, ""))
+	assert.Equal(&CursorOptions{Limit: MaxLimit, Cursor: "foo"}, NewCursorOptions(MaxLimit+1, "foo"))
+}
+
+func TestCursorEncoding(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now().UTC()
+	values := []interface{}{now, "tst_1", 3, 1.5, true, []byte("foo"), sql.NullString{String: ":name", Valid: true}}
+	cursor, err := encodeCursor("test_record", "created_on", values)
+	assert.NoError(err)
+
+	actual, err := decodeCursor(cursor, "test_record", "created_on", len(values))
+	assert.NoError(err)
+	assert.Equal([]interface{}{now, "tst_1", int64(3), 1.5, true, []byte("foo"), ":name"}, actual)
+
+	_, err = decodeCursor(cursor, "test_duper", "created_on", len(values))
+	assert.EqualError(err, "invalid cursor")
+	_, err = decodeCursor(cursor, "test_record", "created_on", 2)
+	assert.EqualError(err, "invalid cursor")
+	_, err = decodeCursor("not a cursor", "test_record", "created_on", len(values))
+	assert.EqualError(err, "invalid cursor")
+
+	_, err = encodeCursor("test_record", "place", []interface{}{sql.NullString{}})
+	assert.IsType(&ValidationError{}, err)
+}
+
+func TestKeysetCondition(t *testing.T) {
+	assert := assert.New(t)
+	columns := []qb.TableField{TestMeta.CreatedOn, TestMeta.ID}
+	actual, values := keysetCondition(columns, qb.Ascending, []interface{}{1, ":id"}).SQL()
+	assert.Equal([]interface{}{1, 1, ":id"}, values)
+	assert.Equal("(`test_record`.`created_on` > ? OR (`test_record`.`created_on` = ? AND `test_record`.`id` > ?))",
+		actual)
+
+	actual, values = keysetCondition(columns[1:], qb.Descending, []interface{}{"tst"}).SQL()
+	assert.Equal([]interface{}{"tst"}, values)
+	assert.Equal("`test_record`.`id` < ?", actual)
+}
+
+func TestListWhereCursor(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	place := sql.NullString{String: generator.String(20), Valid: true}
+	expected := map[string]bool{}
+	for i := 0; i < 7; i++ {
+		record := &TestRecord{Name: generator.Name(), Place: place}
+		assert.NoError(spec.DB.Create(record))
+		expected[record.ID] = true
+	}
+
+	actual := map[string]bool{}
+	options := NewCursorOptions(3, "")
+	// the same condition is used for every page
+	condition := TestMeta.Place.Equal(place.String)
+	conditionSQL, _ := condition.SQL()
+	for pages := 1; pages <= 3; pages++ {
+		page := []TestRecord{}
+		cursor, err := spec.DB.ListWhereCursor(&TestRecord{}, &page, condition, options)
+		assert.NoError(err)
+		for _, record := range page {
+			actual[record.ID] = true
+		}
+		if pages < 3 {
+			assert.Len(page, 3)
+			assert.NotEmpty(cursor)
+		} else {
+			assert.Len(page, 1)
+			assert.Empty(cursor)
+		}
+		options.Cursor = cursor
+		actualSQL, _ := condition.SQL()
+		assert.Equal(conditionSQL, actualSQL)
+	}
+	assert.Equal(expected, actual)
+
+	// nil options read the first page of MaxLimit records
+	page := []TestRecord{}
+	cursor, err := spec.DB.ListWhereCursor(&TestRecord{}, &page, condition, nil)
+	assert.NoError(err)
+	assert.Len(page, 7)
+	assert.Empty(cursor)
+}
+
+func TestListCursorContext(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err := spec.DB.ListCursorContext(ctx, &TestRecord{}, &[]TestRecord{}, NewCursorOptions(3, ""))
+	assert.IsType(&DeadlineExceededError{}, err)
+	_, err = spec.DB.ListWhereCursorContext(ctx, &TestRecord{}, &[]TestRecord{}, TestMeta.Name.Equal("x"), nil)
+	assert.IsType(&DeadlineExceededError{}, err)
+}
+
+func TestListCursorInvalid(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	_, err := spec.DB.ListCursor(&TestRecord{}, &[]TestRecord{}, NewCursorOptions(3, "bad"))
+	assert.EqualError(err, "invalid cursor")
+	_, err = spec.DB.ListCursor(&TestRecord{}, []TestRecord{}, NewCursorOptions(3, ""))
+	assert.IsType(&NotAPointerError{}, err)
+}
@@ -1,8 +1,10 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/go-sql-driver/mysql"
@@ -41,6 +43,8 @@ const (
 	Delete = "DELETE"
 	// Update indicates an UPDATE statement triggered the error
 	Update = "UPDATE"
+	// Transaction indicates beginning or committing a transaction triggered the error
+	Transaction = "TRANSACTION"
 )
 
 const (
@@ -48,16 +52,35 @@ const (
 	invalidForeignKeyMsg = "invalid reference"
 	dataTooLongMsg       = "data too long"
 	duplicateRecordMsg   = "already exists"
+	deadlineExceededMsg  = "deadline exceeded"
+	deadlockMsg          = "deadlock found"
+	lockWaitTimeoutMsg   = "lock wait timeout exceeded"
 )
 
 const (
 	mysqlDuplicateEntry    = 1062
 	mysqlDataTooLong       = 1406
 	mysqlInvalidForeignKey = 1452
+	mysqlLockWaitTimeout   = 1205
+	mysqlDeadlock          = 1213
 )
 
 const primaryKeyConstraintCheck = "for key 'PRIMARY'"
 
+// extended result codes of SQLite errors, the primary result code is the low byte
+const (
+	sqliteBusy                 = 5
+	sqliteLocked               = 6
+	sqliteTooBig               = 18
+	sqliteConstraintForeignKey = 787
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+)
+
+// sqliteErrorType is the error type of the github.com/mattn/go-sqlite3 driver, it is matched by name so that the
+// driver is only a dependency of the applications that use it
+const sqliteErrorType = "github.com/mattn/go-sqlite3.Error"
+
 // TranslateError converts a mysql or other obtuse errors into discrete explicit errors
 func TranslateError(err error, action SQLQueryType, stmt string, logger log.Logger) errors.TracerError {
 	if nil == err {
@@ -66,6 +89,12 @@ func TranslateError(err error, action SQLQueryType, stmt string, logger log.Logg
 	if sql.ErrNoRows == err {
 		return NewNotFoundError()
 	}
+	if context.DeadlineExceeded == err {
+		return NewDeadlineExceededError(action, stmt, err, logger)
+	}
+	if code, ok := sqliteErrorCode(err); ok {
+		return translateSQLiteError(code, err, action, stmt, logger)
+	}
 	driverErr, ok := err.(*mysql.MySQLError)
 	if !ok {
 		return NewSystemError(action, stmt, err, logger)
@@ -83,6 +112,49 @@ func TranslateError(err error, action SQLQueryType, stmt string, logger log.Logg
 	// Invalid foreign key
 	case mysqlInvalidForeignKey:
 		return NewInvalidForeignKeyError(action, stmt, err, logger)
+	// Deadlock found when trying to get lock
+	case mysqlDeadlock:
+		return NewDeadlockError(action, stmt, err, logger)
+	// Lock wait timeout exceeded
+	case mysqlLockWaitTimeout:
+		return NewLockWaitTimeoutError(action, stmt, err, logger)
+	default:
+		return NewExecutionError(action, stmt, err, logger)
+	}
+}
+
+// sqliteErrorCode returns the extended result code of a SQLite driver error
+func sqliteErrorCode(err error) (int, bool) {
+	value := reflect.ValueOf(err)
+	if reflect.Ptr == value.Kind() && !value.IsNil() {
+		value = value.Elem()
+	}
+	if reflect.Struct != value.Kind() || sqliteErrorType != value.Type().PkgPath()+"."+value.Type().Name() {
+		return 0, false
+	}
+	code := value.FieldByName("ExtendedCode")
+	if !code.IsValid() || reflect.Int != code.Kind() {
+		return 0, false
+	}
+	return int(code.Int()), true
+}
+
+// translateSQLiteError converts the extended result code of a SQLite error into the same errors as TranslateError
+func translateSQLiteError(code int, err error, action SQLQueryType, stmt string, logger log.Logger) errors.TracerError {
+	switch code {
+	case sqliteConstraintPrimaryKey:
+		return NewDuplicateRecordError(action, stmt, err, logger)
+	case sqliteConstraintUnique:
+		return NewUniqueConstraintError(action, stmt, err, logger)
+	case sqliteConstraintForeignKey:
+		return NewInvalidForeignKeyError(action, stmt, err, logger)
+	}
+	switch code & 0xff {
+	case sqliteTooBig:
+		return NewDataTooLongError(action, stmt, err, logger)
+	// the database is locked by another connection, SQLite does not detect deadlocks
+	case sqliteBusy, sqliteLocked:
+		return NewLockWaitTimeoutError(action, stmt, err, logger)
 	default:
 		return NewExecutionError(action, stmt, err, logger)
 	}
@@ -285,3 +357,160 @@ func NewInvalidForeignKeyError(action SQLQueryType, stmt string, err error, logg
 	logger.Error(e)
 	return e
 }
+
+// DeadlockError is returned when a mysql error #1213 occurs, the transaction has been rolled back
+type DeadlockError struct {
+	SQLExecutionError
+}
+
+// NewDeadlockError logs the error and returns an instantiated DeadlockError
+func NewDeadlockError(action SQLQueryType, stmt string, err error, logger log.Logger) errors.TracerError {
+	e := &DeadlockError{
+		SQLExecutionError{ErrMsg: err.Error(),
+			ReferenceID: generator.ID(dbErrPrefix),
+			Action:      action,
+			message:     deadlockMsg,
+			Stmt:        stmt,
+			trace:       errors.GetStackTrace(),
+		},
+	}
+	logger.Error(e)
+	return e
+}
+
+// LockWaitTimeoutError is returned when a mysql error #1205 occurs
+type LockWaitTimeoutError struct {
+	SQLExecutionError
+}
+
+// NewLockWaitTimeoutError logs the error and returns an instantiated LockWaitTimeoutError
+func NewLockWaitTimeoutError(action SQLQueryType, stmt string, err error, logger log.Logger) errors.TracerError {
+	e := &LockWaitTimeoutError{
+		SQLExecutionError{ErrMsg: err.Error(),
+			ReferenceID: generator.ID(dbErrPrefix),
+			Action:      action,
+			message:     lockWaitTimeoutMsg,
+			Stmt:        stmt,
+			trace:       errors.GetStackTrace(),
+		},
+	}
+	logger.Error(e)
+	return e
+}
+
+// DeadlineExceededError is returned when a query is cancelled because the deadline of its context was exceeded
+type DeadlineExceededError struct {
+	SQLExecutionError
+}
+
+// NewDeadlineExceededError logs the error and returns an instantiated DeadlineExceededError
+func NewDeadlineExceededError(action SQLQueryType, stmt string, err error, logger log.Logger) errors.TracerError {
+	e := &DeadlineExceededError{
+		SQLExecutionError{ErrMsg: err.Error(),
+			ReferenceID: generator.ID(dbErrPrefix),
+			Action:      action,
+			message:     deadlineExceededMsg,
+			Stmt:        stmt,
+			trace:       errors.GetStackTrace(),
+		},
+	}
+	logger.Error(e)
+	return e
+}
+
+// BatchError is returned when some of the records in a CreateMany or UpsertMany could not be written
+type BatchError struct {
+	// Errors for each record that failed, keyed by the index of the record in the batch
+	Errors map[int]errors.TracerError
+	trace  []string
+}
+
+// NewBatchError returns a BatchError for the passed record errors with a stack trace
+func NewBatchError(errs map[int]errors.TracerError) errors.TracerError {
+	return &BatchError{
+		Errors: errs,
+		trace:  errors.GetStackTrace(),
+	}
+}
+
+// Error prints a BatchError
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d records in the batch could not be written", len(e.Errors))
+}
+
+// Trace returns the stack trace for the error
+func (e *BatchError) Trace() []string {
+	return e.trace
+}
+
+// StaleRecordError is returned when a versioned Record is updated after its row was changed by another update
+type StaleRecordError struct {
+	Table string
+	trace []string
+}
+
+// NewStaleRecordError returns a StaleRecordError for the passed table with a stack trace
+func NewStaleRecordError(table string) errors.TracerError {
+	return &StaleRecordError{
+		Table: table,
+		trace: errors.GetStackTrace(),
+	}
+}
+
+// Error prints a StaleRecordError
+func (e *StaleRecordError) Error() string {
+	return fmt.Sprintf("%s record has been modified since it was read", e.Table)
+}
+
+// Trace returns the stack trace for the error
+func (e *StaleRecordError) Trace() []string {
+	return e.trace
+}
+
+// SchemaDriftError is returned when the schema of the database does not match the qb.Table definitions
+type SchemaDriftError struct {
+	Diff  *SchemaDiff
+	trace []string
+}
+
+// NewSchemaDriftError returns a SchemaDriftError for the passed differences with a stack trace
+func NewSchemaDriftError(diff *SchemaDiff) errors.TracerError {
+	return &SchemaDriftError{
+		Diff:  diff,
+		trace: errors.GetStackTrace(),
+	}
+}
+
+// Error prints a SchemaDriftError
+func (e *SchemaDriftError) Error() string {
+	return fmt.Sprintf("database schema does not match table definitions:\n%s", e.Diff)
+}
+
+// Trace returns the stack trace for the error
+func (e *SchemaDriftError) Trace() []string {
+	return e.trace
+}
+
+// MigrationError is returned when migrations cannot be loaded or applied
+type MigrationError struct {
+	Err   error
+	trace []string
+}
+
+// NewMigrationError wraps the error returned by migrate with a stack trace
+func NewMigrationError(err error) errors.TracerError {
+	return &MigrationError{
+		Err:   err,
+		trace: errors.GetStackTrace(),
+	}
+}
+
+// Error prints a MigrationError
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("migration failed: %s", e.Err)
+}
+
+// Trace returns the stack trace for the error
+func (e *MigrationError) Trace() []string {
+	return e.trace
+}
@@ -0,0 +1,34 @@
+// +build sqlite
+
+package database
+
+import (
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/log"
+)
+
+func TestTranslateSQLiteError(t *testing.T) {
+	assert := assert.New(t)
+	logger := log.NewStackLogger()
+	testData := []struct {
+		code     sqlite3.ErrNoExtended
+		expected error
+	}{
+		{code: sqlite3.ErrConstraintPrimaryKey, expected: &DuplicateRecordError{}},
+		{code: sqlite3.ErrConstraintUnique, expected: &UniqueConstraintError{}},
+		{code: sqlite3.ErrConstraintForeignKey, expected: &InvalidForeignKeyError{}},
+		{code: sqlite3.ErrTooBig.Extend(0), expected: &DataTooLongError{}},
+		{code: sqlite3.ErrBusy.Extend(1), expected: &LockWaitTimeoutError{}},
+		{code: sqlite3.ErrLocked.Extend(0), expected: &LockWaitTimeoutError{}},
+		{code: sqlite3.ErrConstraintNotNull, expected: &SQLExecutionError{}},
+	}
+	for _, data := range testData {
+		err := sqlite3.Error{Code: sqlite3.ErrNo(data.code & 0xff), ExtendedCode: data.code}
+		assert.IsType(data.expected, TranslateError(err, Insert, "", logger))
+		assert.IsType(data.expected, TranslateError(&err, Insert, "", logger))
+	}
+}
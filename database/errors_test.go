@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"strings"
 	"testing"
@@ -63,16 +64,26 @@ func TestNewInvalidForeignKeyError(t *testing.T) {
 	assert.Contains(t, err.Error(), err.message)
 }
 
+func TestNewDeadlineExceededError(t *testing.T) {
+	err := NewDeadlineExceededError(Select, "bar", context.DeadlineExceeded, log.NewStackLogger()).(*DeadlineExceededError)
+	assert.True(t, strings.HasPrefix(err.ReferenceID, dbErrPrefix))
+	assert.Contains(t, err.Error(), err.ReferenceID)
+	assert.Contains(t, err.Error(), deadlineExceededMsg)
+}
+
 func TestTranslateError(t *testing.T) {
 	testData := []struct {
 		err      error
 		expected error
 	}{
 		{err: sql.ErrNoRows, expected: &NotFoundError{}},
+		{err: context.DeadlineExceeded, expected: &DeadlineExceededError{}},
 		{err: &mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "foo ... " + primaryKeyConstraintCheck}, expected: &DuplicateRecordError{}},
 		{err: &mysql.MySQLError{Number: mysqlDuplicateEntry}, expected: &UniqueConstraintError{}},
 		{err: &mysql.MySQLError{Number: mysqlDataTooLong}, expected: &DataTooLongError{}},
 		{err: &mysql.MySQLError{Number: mysqlInvalidForeignKey}, expected: &InvalidForeignKeyError{}},
+		{err: &mysql.MySQLError{Number: mysqlDeadlock}, expected: &DeadlockError{}},
+		{err: &mysql.MySQLError{Number: mysqlLockWaitTimeout}, expected: &LockWaitTimeoutError{}},
 		{err: &mysql.MySQLError{}, expected: &SQLExecutionError{}},
 		{err: errors.New("foo"), expected: &SQLSystemError{}},
 	}
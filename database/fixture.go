@@ -0,0 +1,466 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/Kasita-Inc/gadget/database/qb"
+	"github.com/Kasita-Inc/gadget/errors"
+	"github.com/Kasita-Inc/gadget/fileutil"
+)
+
+// FixtureFormat is the encoding of a fixture file
+type FixtureFormat string
+
+const (
+	// YAMLFixture files are named {table}.yaml or {table}.yml
+	YAMLFixture FixtureFormat = "yaml"
+	// JSONFixture files are named {table}.json
+	JSONFixture FixtureFormat = "json"
+)
+
+// fixtureExtensions are tried in order when looking for the fixture file of a table
+var fixtureExtensions = []string{".yaml", ".yml", ".json"}
+
+// Fixtures loads rows for a set of Records from fixture files in an fs.FS. Each file is named after the table of the
+// Record and contains a list of rows keyed by column name:
+//
+//	# test_record.yaml
+//	- id: tst1
+//	  name: first
+//
+// Rows without a primary key are given the one generated by the Record's Initialize.
+type Fixtures struct {
+	db      *Database
+	source  fs.FS
+	dir     string
+	records []Record
+}
+
+// NewFixtures for the Records with fixture files in the directory of the source, use "." for the root of the source.
+// The Records are loaded in dependency order, a table that has a qb.BelongsTo relation to another table, or that
+// another table has a qb.HasMany relation to, is loaded after it. A ValidationError is returned when the relations
+// form a cycle.
+func NewFixtures(db *Database, source fs.FS, dir string, records ...Record) (*Fixtures, errors.TracerError) {
+	ordered, err := fixtureOrder(records)
+	if nil != err {
+		return nil, err
+	}
+	return &Fixtures{db: db, source: source, dir: dir, records: ordered}, nil
+}
+
+// Tables returns the names of the tables in the order they are loaded
+func (f *Fixtures) Tables() []string {
+	tables := make([]string, len(f.records))
+	for i, record := range f.records {
+		tables[i] = record.Meta().GetName()
+	}
+	return tables
+}
+
+// Load inserts the rows of the fixture files in a single transaction, tables without a fixture file are skipped
+func (f *Fixtures) Load() errors.TracerError {
+	return f.LoadContext(context.Background())
+}
+
+// LoadContext inserts the rows of the fixture files in a single transaction, the transaction is cancelled with the
+// context
+func (f *Fixtures) LoadContext(ctx context.Context) errors.TracerError {
+	return f.db.InTxContext(ctx, func(tx *sqlx.Tx) error {
+		return f.loadTx(ctx, tx)
+	})
+}
+
+// LoadTx inserts the rows of the fixture files using the transaction
+func (f *Fixtures) LoadTx(tx *sqlx.Tx) errors.TracerError {
+	return f.loadTx(context.Background(), tx)
+}
+
+// Truncate deletes every row of the tables in reverse dependency order in a single transaction. The rows are deleted
+// rather than using TRUNCATE TABLE which cannot be rolled back.
+func (f *Fixtures) Truncate() errors.TracerError {
+	return f.db.InTx(func(tx *sqlx.Tx) error {
+		return f.truncateTx(context.Background(), tx)
+	})
+}
+
+// TruncateTx deletes every row of the tables in reverse dependency order using the transaction
+func (f *Fixtures) TruncateTx(tx *sqlx.Tx) errors.TracerError {
+	return f.truncateTx(context.Background(), tx)
+}
+
+// Reload truncates the tables and loads the fixture files in a single transaction, use it to reset the database
+// between tests
+func (f *Fixtures) Reload() errors.TracerError {
+	return f.ReloadContext(context.Background())
+}
+
+// ReloadContext truncates the tables and loads the fixture files in a single transaction, the transaction is cancelled
+// with the context
+func (f *Fixtures) ReloadContext(ctx context.Context) errors.TracerError {
+	return f.db.InTxContext(ctx, func(tx *sqlx.Tx) error {
+		if err := f.truncateTx(ctx, tx); nil != err {
+			return err
+		}
+		return f.loadTx(ctx, tx)
+	})
+}
+
+// Export writes every row of the tables, including soft deleted rows, to a fixture file per table in the directory.
+// The rows are read from the primary and ordered by primary key.
+func (f *Fixtures) Export(dir string, format FixtureFormat) errors.TracerError {
+	if YAMLFixture != format && JSONFixture != format {
+		return NewValidationError("unknown fixture format %s", format)
+	}
+	if _, err := fileutil.EnsureDir(dir, 0777); nil != err {
+		return errors.Wrap(err)
+	}
+	for _, record := range f.records {
+		rows, err := f.exportRows(record)
+		if nil != err {
+			return err
+		}
+		data, err := encodeFixture(rows, format)
+		if nil != err {
+			return err
+		}
+		filename := filepath.Join(dir, fmt.Sprintf("%s.%s", record.Meta().GetName(), format))
+		if err := ioutil.WriteFile(filename, data, 0644); nil != err {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (f *Fixtures) loadTx(ctx context.Context, tx *sqlx.Tx) errors.TracerError {
+	for _, record := range f.records {
+		rows, err := f.readFixture(record.Meta().GetName())
+		if nil != err {
+			return err
+		}
+		for i, row := range rows {
+			if err := f.insertRow(ctx, tx, record, row); nil != err {
+				if _, ok := err.(*ValidationError); ok {
+					return NewValidationError("%s row %d: %s", record.Meta().GetName(), i, err.Error())
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *Fixtures) truncateTx(ctx context.Context, tx *sqlx.Tx) errors.TracerError {
+	for i := len(f.records) - 1; i >= 0; i-- {
+		meta := f.records[i].Meta()
+		stmt, values, err := qb.Delete(meta).
+			Where(meta.PrimaryKey().IsNotNull()).
+			Dialect(f.db.Dialect).
+			SQL()
+		if nil != err {
+			return errors.Wrap(err)
+		}
+		if _, err = f.db.execContext(ctx, tx, meta.GetName(), Delete, stmt, values...); nil != err {
+			return TranslateError(err, Delete, stmt, f.db.Logger)
+		}
+	}
+	return nil
+}
+
+// insertRow inserts the columns of the row in column name order, generating the primary key when it is missing
+func (f *Fixtures) insertRow(ctx context.Context, tx *sqlx.Tx, record Record,
+	row map[string]interface{}) errors.TracerError {
+	meta := record.Meta()
+	known := map[string]qb.TableField{}
+	for _, column := range fixtureColumns(meta) {
+		known[column.GetName()] = column
+	}
+	pk := meta.PrimaryKey().GetName()
+	if _, ok := row[pk]; !ok {
+		if value, ok := f.generateKey(record); ok {
+			row[pk] = value
+		}
+	}
+
+	names := make([]string, 0, len(row))
+	for name := range row {
+		if _, ok := known[name]; !ok {
+			return NewValidationError("%s is not a column of %s", name, meta.GetName())
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	columns := make([]qb.TableField, len(names))
+	values := make([]interface{}, len(names))
+	for i, name := range names {
+		value, err := fixtureValue(row[name])
+		if nil != err {
+			return NewValidationError("column %s: %s", name, err)
+		}
+		columns[i], values[i] = known[name], value
+	}
+
+	stmt, args, err := qb.Insert(columns...).Values(values...).Dialect(f.db.Dialect).SQL()
+	if nil != err {
+		return errors.Wrap(err)
+	}
+	if _, err = f.db.execContext(ctx, tx, meta.GetName(), Insert, stmt, args...); nil != err {
+		return TranslateError(err, Insert, stmt, f.db.Logger)
+	}
+	return nil
+}
+
+// generateKey returns the primary key set by Initialize on a new instance of the Record, false when it does not set
+// one such as for an auto increment key
+func (f *Fixtures) generateKey(record Record) (interface{}, bool) {
+	instance, ok := reflect.New(recordType(record)).Interface().(Record)
+	if !ok {
+		return nil, false
+	}
+	instance.Initialize()
+	field, ok := f.db.Mapper.FieldMap(reflect.ValueOf(instance))[record.Meta().PrimaryKey().GetName()]
+	if !ok || field.IsZero() {
+		return nil, false
+	}
+	return field.Interface(), true
+}
+
+// readFixture returns the rows of the fixture file for the table, none when the table does not have a fixture file
+func (f *Fixtures) readFixture(table string) ([]map[string]interface{}, errors.TracerError) {
+	for _, extension := range fixtureExtensions {
+		filename := path.Join(f.dir, table+extension)
+		data, err := fs.ReadFile(f.source, filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if nil != err {
+			return nil, errors.Wrap(err)
+		}
+		rows := []map[string]interface{}{}
+		if ".json" == extension {
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.UseNumber()
+			err = decoder.Decode(&rows)
+		} else {
+			err = yaml.Unmarshal(data, &rows)
+		}
+		if nil != err {
+			return nil, NewValidationError("%s: %s", filename, err)
+		}
+		return rows, nil
+	}
+	return nil, nil
+}
+
+// exportRows reads every row of the table and returns the values of its columns
+func (f *Fixtures) exportRows(record Record) ([]yaml.MapSlice, errors.TracerError) {
+	meta := record.Meta()
+	stmt, values, err := qb.Select(meta.AllColumns()).
+		From(meta).
+		OrderBy(meta.PrimaryKey(), qb.Ascending).
+		Dialect(f.db.Dialect).
+		SQL(qb.NoLimit, 0)
+	if nil != err {
+		return nil, errors.Wrap(err)
+	}
+	target := reflect.New(reflect.SliceOf(reflect.PtrTo(recordType(record))))
+	if err = f.db.selectContext(context.Background(), f.db.DB, meta.GetName(), target.Interface(), stmt,
+		values...); nil != err {
+		return nil, TranslateError(err, Select, stmt, f.db.Logger)
+	}
+	columns := fixtureColumns(meta)
+	rows := make([]yaml.MapSlice, target.Elem().Len())
+	for i := range rows {
+		obj := target.Elem().Index(i).Interface().(Record)
+		values, err := f.db.columnValues(obj, columns)
+		if nil != err {
+			return nil, err
+		}
+		row := make(yaml.MapSlice, len(columns))
+		for j, column := range columns {
+			row[j] = yaml.MapItem{Key: column.GetName(), Value: exportValue(values[j])}
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// fixtureColumns returns the primary key, read and write columns of the table without duplicates
+func fixtureColumns(meta qb.Table) []qb.TableField {
+	columns := appendIfMissing([]qb.TableField{}, meta.PrimaryKey())
+	for _, column := range meta.ReadColumns() {
+		columns = appendIfMissing(columns, column)
+	}
+	for _, column := range meta.WriteColumns() {
+		columns = appendIfMissing(columns, column)
+	}
+	return columns
+}
+
+// fixtureValue converts a value decoded from a fixture file to a value that can be bound to a statement, lists and
+// maps are stored as JSON
+func fixtureValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); nil == err {
+			return i, nil
+		}
+		return v.Float64()
+	case map[interface{}]interface{}, map[string]interface{}, []interface{}:
+		data, err := json.Marshal(jsonValue(v))
+		if nil != err {
+			return nil, err
+		}
+		return string(data), nil
+	}
+	return value, nil
+}
+
+// jsonValue converts the maps decoded from yaml, which are keyed by interface{}, to maps that can be encoded as JSON
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[key] = jsonValue(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = jsonValue(item)
+		}
+		return converted
+	}
+	return value
+}
+
+// exportValue converts the value of a field to a value that is written to a fixture file and can be loaded again
+func exportValue(value interface{}) interface{} {
+	converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+	if nil != err {
+		return fmt.Sprint(value)
+	}
+	switch v := converted.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(mysqlTimestampFormat)
+	}
+	return converted
+}
+
+// encodeFixture encodes the rows in the format keeping the column order for yaml
+func encodeFixture(rows []yaml.MapSlice, format FixtureFormat) ([]byte, errors.TracerError) {
+	var data []byte
+	var err error
+	if YAMLFixture == format {
+		data, err = yaml.Marshal(rows)
+	} else {
+		objects := make([]map[string]interface{}, len(rows))
+		for i, row := range rows {
+			objects[i] = make(map[string]interface{}, len(row))
+			for _, item := range row {
+				objects[i][item.Key.(string)] = item.Value
+			}
+		}
+		data, err = json.MarshalIndent(objects, "", "  ")
+	}
+	if nil != err {
+		return nil, errors.Wrap(err)
+	}
+	return data, nil
+}
+
+// fixtureOrder sorts the records so that each table is loaded after the tables it depends on, keeping the passed
+// order otherwise
+func fixtureOrder(records []Record) ([]Record, errors.TracerError) {
+	tables := map[string]bool{}
+	for _, record := range records {
+		tables[record.Meta().GetName()] = true
+	}
+	dependencies := map[string]map[string]bool{}
+	for _, record := range records {
+		name := record.Meta().GetName()
+		if nil == dependencies[name] {
+			dependencies[name] = map[string]bool{}
+		}
+		related, ok := record.Meta().(qb.RelatedTable)
+		if !ok {
+			continue
+		}
+		for _, relation := range related.Relations() {
+			other := relation.Table.GetName()
+			if other == name || !tables[other] {
+				continue
+			}
+			if qb.BelongsTo == relation.Type {
+				dependencies[name][other] = true
+			} else {
+				if nil == dependencies[other] {
+					dependencies[other] = map[string]bool{}
+				}
+				dependencies[other][name] = true
+			}
+		}
+	}
+
+	ordered := make([]Record, 0, len(records))
+	loaded := map[string]bool{}
+	remaining := records
+	for len(remaining) > 0 {
+		next := []Record{}
+		for _, record := range remaining {
+			name := record.Meta().GetName()
+			ready := true
+			for dependency := range dependencies[name] {
+				ready = ready && loaded[dependency]
+			}
+			if ready {
+				ordered = append(ordered, record)
+				loaded[name] = true
+			} else {
+				next = append(next, record)
+			}
+		}
+		if len(next) == len(remaining) {
+			names := make([]string, len(next))
+			for i, record := range next {
+				names[i] = record.Meta().GetName()
+			}
+			return nil, NewValidationError("fixture tables have a dependency cycle: %s", strings.Join(names, ", "))
+		}
+		remaining = next
+	}
+	return ordered, nil
+}
+
+// recordType returns the struct type of the Record
+func recordType(record Record) reflect.Type {
+	t := reflect.TypeOf(record)
+	for reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixtureOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	fixtures, err := NewFixtures(&Database{}, fstest.MapFS{}, ".",
+		&TestItemRecord{}, &TestArchiveRecord{}, &TestRecord{})
+	assert.NoError(err)
+	assert.Equal([]string{"test_archive", "test_record", "test_item"}, fixtures.Tables())
+}
+
+func TestReadFixture(t *testing.T) {
+	assert := assert.New(t)
+	source := fstest.MapFS{
+		"fixtures/test_record.yaml": {Data: []byte("- id: tst1\n  name: first\n  place:\n    city: Austin\n")},
+		"fixtures/test_item.json":   {Data: []byte(`[{"id": "itm1", "record_id": "tst1", "name": 12345678901}]`)},
+		"fixtures/test_archive.yml": {Data: []byte("not: [a list")},
+	}
+	fixtures := &Fixtures{source: source, dir: "fixtures"}
+
+	rows, err := fixtures.readFixture("test_record")
+	assert.NoError(err)
+	if assert.Len(rows, 1) {
+		assert.Equal("tst1", rows[0]["id"])
+		value, err := fixtureValue(rows[0]["place"])
+		assert.NoError(err)
+		assert.Equal(`{"city":"Austin"}`, value)
+	}
+
+	rows, err = fixtures.readFixture("test_item")
+	assert.NoError(err)
+	if assert.Len(rows, 1) {
+		value, err := fixtureValue(rows[0]["name"])
+		assert.NoError(err)
+		assert.Equal(int64(12345678901), value)
+	}
+
+	_, err = fixtures.readFixture("test_archive")
+	assert.IsType(&ValidationError{}, err)
+
+	rows, err = fixtures.readFixture("test_missing")
+	assert.NoError(err)
+	assert.Empty(rows)
+}
+
+func TestFixtures(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+	source := fstest.MapFS{
+		"test_archive.yaml": {Data: []byte("- id: arc-fixture\n  name: loaded\n- name: generated\n")},
+	}
+	fixtures, err := NewFixtures(spec.DB, source, ".", &TestArchiveRecord{})
+	assert.NoError(err)
+
+	assert.NoError(fixtures.Reload())
+	records := []TestArchiveRecord{}
+	assert.NoError(spec.DB.WithDeleted().ListWhere(&TestArchiveRecord{}, &records, TestArchiveMeta.ID.IsNotNull()))
+	assert.Len(records, 2)
+
+	assert.NoError(fixtures.Reload())
+	loaded := &TestArchiveRecord{}
+	assert.NoError(spec.DB.Read(loaded, NewPrimaryKey("arc-fixture")))
+	assert.Equal("loaded", loaded.Name)
+
+	dir, tmpErr := ioutil.TempDir("", "fixtures")
+	assert.NoError(tmpErr)
+	defer os.RemoveAll(dir)
+	assert.NoError(fixtures.Export(dir, JSONFixture))
+	data, tmpErr := ioutil.ReadFile(filepath.Join(dir, "test_archive.json"))
+	assert.NoError(tmpErr)
+	exported := []map[string]interface{}{}
+	assert.NoError(json.Unmarshal(data, &exported))
+	assert.Len(exported, 2)
+
+	invalid, err := NewFixtures(spec.DB, fstest.MapFS{
+		"test_archive.yaml": {Data: []byte("- id: arc-invalid\n  color: red\n")},
+	}, ".", &TestArchiveRecord{})
+	assert.NoError(err)
+	assert.IsType(&ValidationError{}, invalid.Reload())
+	assert.NoError(spec.DB.Read(loaded, NewPrimaryKey("arc-fixture")))
+}
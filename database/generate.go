@@ -0,0 +1,282 @@
+package database
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"text/template"
+
+	"github.com/Kasita-Inc/gadget/errors"
+	"github.com/Kasita-Inc/gadget/stringutil"
+)
+
+// GenerateOptions control the qb.Table implementation generated for a struct
+type GenerateOptions struct {
+	// Table is the name of the table, defaults to the struct name converted to snake case
+	Table string
+	// SkipRecordMeta does not generate the Meta method of the Record
+	SkipRecordMeta bool
+}
+
+// generatedColumn is a struct field that maps to a column of the table
+type generatedColumn struct {
+	Field    string
+	Column   string
+	ReadOnly bool
+}
+
+type generatedTable struct {
+	Package    string
+	Type       string
+	Meta       string
+	Var        string
+	Table      string
+	Columns    []generatedColumn
+	PrimaryKey string
+	Sort       string
+	Direction  string
+	Version    string
+	SoftDelete string
+	RecordMeta bool
+}
+
+const tableTemplate = `// Code generated by qbgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"github.com/Kasita-Inc/gadget/database/qb"
+)
+
+// {{.Meta}} defines the {{.Table}} table
+type {{.Meta}} struct {
+	alias string
+{{- range .Columns}}
+	{{.Field}} qb.TableField
+{{- end}}
+}
+
+// GetName returns the name of the table
+func (m *{{.Meta}}) GetName() string {
+	return "{{.Table}}"
+}
+
+// GetAlias returns the alias of the table
+func (m *{{.Meta}}) GetAlias() string {
+	return m.alias
+}
+
+// PrimaryKey returns the primary key column of the table
+func (m *{{.Meta}}) PrimaryKey() qb.TableField {
+	return m.{{.PrimaryKey}}
+}
+
+// AllColumns returns the * column of the table
+func (m *{{.Meta}}) AllColumns() qb.TableField {
+	return qb.TableField{Table: m.GetName(), Name: "*"}
+}
+
+// SortBy returns the default ordering of the table
+func (m *{{.Meta}}) SortBy() (qb.TableField, qb.OrderDirection) {
+	return m.{{.Sort}}, qb.{{.Direction}}
+}
+
+// ReadColumns returns the columns read from the table
+func (m *{{.Meta}}) ReadColumns() []qb.TableField {
+	return []qb.TableField{
+{{- range .Columns}}
+		m.{{.Field}},
+{{- end}}
+	}
+}
+
+// WriteColumns returns the columns written to the table
+func (m *{{.Meta}}) WriteColumns() []qb.TableField {
+	return []qb.TableField{
+{{- range .Columns}}{{if not .ReadOnly}}
+		m.{{.Field}},
+{{- end}}{{end}}
+	}
+}
+{{if .Version}}
+// Version returns the column used for optimistic concurrency
+func (m *{{.Meta}}) Version() qb.TableField {
+	return m.{{.Version}}
+}
+{{end}}{{if .SoftDelete}}
+// SoftDelete returns the column that marks a row as deleted
+func (m *{{.Meta}}) SoftDelete() qb.TableField {
+	return m.{{.SoftDelete}}
+}
+{{end}}
+// Alias returns a copy of the table using the passed alias
+func (m *{{.Meta}}) Alias(alias string) *{{.Meta}} {
+	return &{{.Meta}}{
+		alias: alias,
+{{- range .Columns}}
+		{{.Field}}: qb.TableField{Name: "{{.Column}}", Table: alias},
+{{- end}}
+	}
+}
+
+// {{.Var}} is the qb.Table for {{.Type}}
+var {{.Var}} = (&{{.Meta}}{}).Alias("{{.Table}}")
+{{if .RecordMeta}}
+// Meta returns the qb.Table for {{.Type}}
+func (record *{{.Type}}) Meta() qb.Table {
+	return {{.Var}}
+}
+{{end}}`
+
+// GenerateTable generates the qb.Table implementation for the struct named typeName in the passed go source file. The
+// columns are the fields with a db tag, the qb tag sets the options of a column: 'pk' marks the primary key (defaults
+// to the 'id' column), 'readonly' excludes the column from WriteColumns, 'sort' or 'sort=desc' sets SortBy (defaults
+// to the primary key), 'version' implements qb.VersionedTable using the column, which must be an integer, and
+// 'softdelete' implements qb.SoftDeleteTable.
+func GenerateTable(filename string, typeName string, options *GenerateOptions) ([]byte, errors.TracerError) {
+	if nil == options {
+		options = &GenerateOptions{}
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), filename, nil, 0)
+	if nil != err {
+		return nil, errors.Wrap(err)
+	}
+	structType := findStruct(file, typeName)
+	if nil == structType {
+		return nil, NewValidationError("struct %s not found in %s", typeName, filename)
+	}
+
+	table := &generatedTable{
+		Package:    file.Name.Name,
+		Type:       typeName,
+		Meta:       stringutil.LowerCamelCase(typeName) + "Meta",
+		Var:        stringutil.UpperCamelCase(typeName) + "Meta",
+		Table:      options.Table,
+		Direction:  "Ascending",
+		RecordMeta: !options.SkipRecordMeta,
+	}
+	if "" == table.Table {
+		table.Table = stringutil.Underscore(typeName)
+	}
+	for _, field := range structType.Fields.List {
+		if nil == field.Tag || len(field.Names) == 0 {
+			continue
+		}
+		value, err := strconv.Unquote(field.Tag.Value)
+		if nil != err {
+			return nil, errors.Wrap(err)
+		}
+		tag := reflect.StructTag(value)
+		column, dbOptions := stringutil.ParseTag(tag.Get("db"))
+		if "" == column || "-" == column {
+			continue
+		}
+		_, qbOptions := stringutil.ParseTag("," + tag.Get("qb"))
+		for _, name := range field.Names {
+			if err := table.addColumn(name.Name, column, dbOptions, qbOptions); nil != err {
+				return nil, err
+			}
+		}
+	}
+	if len(table.Columns) == 0 {
+		return nil, NewValidationError("struct %s has no fields with a db tag", typeName)
+	}
+	if "" == table.PrimaryKey {
+		for i, column := range table.Columns {
+			if "id" == column.Column {
+				table.PrimaryKey = column.Field
+				table.Columns[i].ReadOnly = true
+			}
+		}
+		if "" == table.PrimaryKey {
+			return nil, NewValidationError("struct %s has no primary key, tag a field with qb:\"pk\"", typeName)
+		}
+	}
+	if "" == table.Sort {
+		table.Sort = table.PrimaryKey
+	}
+
+	buf := &bytes.Buffer{}
+	if err := template.Must(template.New("table").Parse(tableTemplate)).Execute(buf, table); nil != err {
+		return nil, errors.Wrap(err)
+	}
+	source, err := format.Source(buf.Bytes())
+	if nil != err {
+		return nil, errors.Wrap(err)
+	}
+	return source, nil
+}
+
+// WriteTable generates the qb.Table implementation for the struct named typeName in the passed go source file and
+// writes it to output
+func WriteTable(filename string, typeName string, output string, options *GenerateOptions) errors.TracerError {
+	source, err := GenerateTable(filename, typeName, options)
+	if nil != err {
+		return err
+	}
+	return errors.Wrap(ioutil.WriteFile(output, source, 0644))
+}
+
+func (table *generatedTable) addColumn(field string, column string, dbOptions stringutil.TagOptions,
+	qbOptions stringutil.TagOptions) errors.TracerError {
+	generated := generatedColumn{
+		Field:    field,
+		Column:   column,
+		ReadOnly: dbOptions.Contains("read_only"),
+	}
+	for _, option := range qbOptions {
+		switch option {
+		case "pk":
+			if "" != table.PrimaryKey {
+				return NewValidationError("%s has more than one primary key", table.Type)
+			}
+			table.PrimaryKey = field
+			// the primary key is written by Create and never updated
+			generated.ReadOnly = true
+		case "readonly":
+			generated.ReadOnly = true
+		case "sort", "sort=asc", "sort=desc":
+			if "" != table.Sort {
+				return NewValidationError("%s has more than one sort column", table.Type)
+			}
+			table.Sort = field
+			if "sort=desc" == option {
+				table.Direction = "Descending"
+			}
+		case "version":
+			table.Version = field
+			generated.ReadOnly = true
+		case "softdelete":
+			table.SoftDelete = field
+			generated.ReadOnly = true
+		default:
+			return NewValidationError("unknown qb tag option '%s' on %s.%s", option, table.Type, field)
+		}
+	}
+	table.Columns = append(table.Columns, generated)
+	return nil
+}
+
+func findStruct(file *ast.File, typeName string) *ast.StructType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || token.TYPE != gen.Tok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if typeSpec.Name.Name != typeName {
+				continue
+			}
+			if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+				return structType
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const generateSource = `package models
+
+type Widget struct {
+	DefaultRecord
+	ID        string         ` + "`db:\"id\"`" + `
+	Name      string         ` + "`db:\"name\"`" + `
+	Position  int            ` + "`db:\"position\" qb:\"sort=desc\"`" + `
+	Version   int            ` + "`db:\"version\" qb:\"version\"`" + `
+	DeletedOn mysql.NullTime ` + "`db:\"deleted_on\" qb:\"softdelete\"`" + `
+	CreatedOn time.Time      ` + "`db:\"created_on,read_only\"`" + `
+	Ignored   string         ` + "`db:\"-\"`" + `
+	Skip      string
+}
+
+type Gadget struct {
+	Key   string ` + "`db:\"key\" qb:\"pk\"`" + `
+	Value string ` + "`db:\"value\" qb:\"readonly\"`" + `
+}
+
+type Broken struct {
+	Key string ` + "`db:\"key\" qb:\"unknown\"`" + `
+}
+
+type Keyless struct {
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+func writeGenerateSource(t *testing.T) string {
+	fd, err := ioutil.TempFile("", "generate")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	if _, err = fd.WriteString(generateSource); nil != err {
+		t.Fatal(err)
+	}
+	return fd.Name()
+}
+
+func TestGenerateTable(t *testing.T) {
+	assert := assert.New(t)
+	filename := writeGenerateSource(t)
+	defer os.Remove(filename)
+
+	source, err := GenerateTable(filename, "Widget", nil)
+	assert.NoError(err)
+	actual := string(source)
+	assert.Contains(actual, "package models")
+	assert.Contains(actual, "type widgetMeta struct")
+	assert.Contains(actual, `return "widget"`)
+	assert.Contains(actual, "return m.ID\n")
+	assert.Contains(actual, "return m.Position, qb.Descending")
+	assert.Contains(actual, "return []qb.TableField{\n\t\tm.Name,\n\t\tm.Position,\n\t}")
+	assert.Contains(actual, "func (m *widgetMeta) Version() qb.TableField")
+	assert.Contains(actual, "func (m *widgetMeta) SoftDelete() qb.TableField")
+	assert.Contains(actual, `CreatedOn: qb.TableField{Name: "created_on", Table: alias}`)
+	assert.Contains(actual, `var WidgetMeta = (&widgetMeta{}).Alias("widget")`)
+	assert.Contains(actual, "func (record *Widget) Meta() qb.Table")
+	assert.NotContains(actual, "Ignored")
+	assert.NotContains(actual, "Skip")
+
+	source, err = GenerateTable(filename, "Gadget", &GenerateOptions{Table: "gadgets", SkipRecordMeta: true})
+	assert.NoError(err)
+	actual = string(source)
+	assert.Contains(actual, `return "gadgets"`)
+	assert.Contains(actual, "return m.Key, qb.Ascending")
+	assert.Contains(actual, "return []qb.TableField{}")
+	assert.NotContains(actual, "Version()")
+	assert.NotContains(actual, "func (record *Gadget) Meta()")
+}
+
+func TestGenerateTableErrors(t *testing.T) {
+	assert := assert.New(t)
+	filename := writeGenerateSource(t)
+	defer os.Remove(filename)
+
+	_, err := GenerateTable(filename, "Missing", nil)
+	assert.IsType(&ValidationError{}, err)
+	_, err = GenerateTable(filename, "Broken", nil)
+	assert.IsType(&ValidationError{}, err)
+	_, err = GenerateTable(filename, "Keyless", nil)
+	assert.IsType(&ValidationError{}, err)
+	_, err = GenerateTable(filename+".missing", "Widget", nil)
+	assert.Error(err)
+}
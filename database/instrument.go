@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Kasita-Inc/gadget/log"
+)
+
+// QueryEvent describes a statement executed by the Database
+type QueryEvent struct {
+	// Table the statement was executed against
+	Table string
+	// Operation of the statement
+	Operation SQLQueryType
+	// SQL of the statement
+	SQL string
+	// Args bound to the statement, a single Record for named statements
+	Args []interface{}
+	// Duration of the statement, set after it is executed
+	Duration time.Duration
+	// RowsAffected by the statement or the number of rows read by a select, set after it is executed
+	RowsAffected int64
+	// Err returned by the driver, set after it is executed
+	Err error
+}
+
+// QueryHook is called before and after each statement executed by the Database
+type QueryHook interface {
+	// BeforeQuery is called before the statement is executed
+	BeforeQuery(ctx context.Context, event *QueryEvent)
+	// AfterQuery is called after the statement is executed with the duration, rows affected and error populated
+	AfterQuery(ctx context.Context, event *QueryEvent)
+}
+
+// AddHook adds hooks that are called for each statement executed by the Database, it is not safe to add hooks while
+// statements are executing
+func (db *Database) AddHook(hooks ...QueryHook) {
+	db.Hooks = append(db.Hooks, hooks...)
+}
+
+// instrument executes the statement in fn, calling the hooks of the Database around it. fn returns the number of rows
+// affected or read by the statement.
+func (db *Database) instrument(ctx context.Context, table string, operation SQLQueryType, stmt string,
+	args []interface{}, fn func() (int64, error)) error {
+	if len(db.Hooks) == 0 {
+		_, err := fn()
+		return err
+	}
+	event := &QueryEvent{Table: table, Operation: operation, SQL: stmt, Args: args}
+	for _, hook := range db.Hooks {
+		hook.BeforeQuery(ctx, event)
+	}
+	start := time.Now()
+	event.RowsAffected, event.Err = fn()
+	event.Duration = time.Since(start)
+	for _, hook := range db.Hooks {
+		hook.AfterQuery(ctx, event)
+	}
+	return event.Err
+}
+
+// execContext executes the statement with the hooks of the Database
+func (db *Database) execContext(ctx context.Context, execer sqlx.ExecerContext, table string, operation SQLQueryType,
+	stmt string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := db.instrument(ctx, table, operation, stmt, args, func() (int64, error) {
+		var err error
+		result, err = execer.ExecContext(ctx, stmt, args...)
+		return rowsAffected(result, err)
+	})
+	return result, err
+}
+
+// namedExecContext executes the named statement bound to arg with the hooks of the Database
+func (db *Database) namedExecContext(ctx context.Context, tx *sqlx.Tx, table string, operation SQLQueryType,
+	stmt string, arg interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := db.instrument(ctx, table, operation, stmt, []interface{}{arg}, func() (int64, error) {
+		var err error
+		result, err = tx.NamedExecContext(ctx, stmt, arg)
+		return rowsAffected(result, err)
+	})
+	return result, err
+}
+
+// selectContext populates the slice dest with the rows of the query with the hooks of the Database, Tracked Records
+// are snapshotted
+func (db *Database) selectContext(ctx context.Context, queryer sqlx.QueryerContext, table string, dest interface{},
+	stmt string, args ...interface{}) error {
+	err := db.instrument(ctx, table, Select, stmt, args, func() (int64, error) {
+		if err := sqlx.SelectContext(ctx, queryer, dest, stmt, args...); nil != err {
+			return 0, err
+		}
+		return int64(reflect.Indirect(reflect.ValueOf(dest)).Len()), nil
+	})
+	if nil == err {
+		db.snapshot(dest)
+	}
+	return err
+}
+
+// getContext populates dest with a single row of the query with the hooks of the Database, a Tracked Record is
+// snapshotted
+func (db *Database) getContext(ctx context.Context, queryer sqlx.QueryerContext, table string, dest interface{},
+	stmt string, args ...interface{}) error {
+	err := db.instrument(ctx, table, Select, stmt, args, func() (int64, error) {
+		if err := sqlx.GetContext(ctx, queryer, dest, stmt, args...); nil != err {
+			return 0, err
+		}
+		return 1, nil
+	})
+	if nil == err {
+		db.snapshot(dest)
+	}
+	return err
+}
+
+// rowsAffected returns the rows affected by the result of an exec
+func rowsAffected(result sql.Result, err error) (int64, error) {
+	if nil != err {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if nil != err {
+		// not every driver reports the rows affected, the statement itself succeeded
+		return 0, nil
+	}
+	return affected, nil
+}
+
+// SlowQueryLogger is a QueryHook that logs the statements that take longer than a threshold at Warn
+type SlowQueryLogger struct {
+	Logger    log.Logger
+	Threshold time.Duration
+}
+
+// NewSlowQueryLogger logs statements that take longer than the threshold to the logger
+func NewSlowQueryLogger(logger log.Logger, threshold time.Duration) *SlowQueryLogger {
+	return &SlowQueryLogger{Logger: logger, Threshold: threshold}
+}
+
+// BeforeQuery does nothing
+func (l *SlowQueryLogger) BeforeQuery(ctx context.Context, event *QueryEvent) {}
+
+// AfterQuery logs the statement if it took longer than the threshold
+func (l *SlowQueryLogger) AfterQuery(ctx context.Context, event *QueryEvent) {
+	if event.Duration > l.Threshold {
+		l.Logger.Warnf("slow query (%s) %s %s: %s", event.Duration, event.Operation, event.Table, event.SQL)
+	}
+}
+
+// QueryKey identifies the counts of a QueryCounter
+type QueryKey struct {
+	Table     string
+	Operation SQLQueryType
+}
+
+// QueryCount is the number of statements executed for a table and operation
+type QueryCount struct {
+	Count    int64
+	Errors   int64
+	Rows     int64
+	Duration time.Duration
+}
+
+// QueryCounter is a QueryHook that counts the statements executed by table and operation
+type QueryCounter struct {
+	mutex  sync.Mutex
+	counts map[QueryKey]QueryCount
+}
+
+// NewQueryCounter for exposing the statements executed by a Database to metrics
+func NewQueryCounter() *QueryCounter {
+	return &QueryCounter{counts: make(map[QueryKey]QueryCount)}
+}
+
+// BeforeQuery does nothing
+func (c *QueryCounter) BeforeQuery(ctx context.Context, event *QueryEvent) {}
+
+// AfterQuery counts the statement
+func (c *QueryCounter) AfterQuery(ctx context.Context, event *QueryEvent) {
+	key := QueryKey{Table: event.Table, Operation: event.Operation}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	count := c.counts[key]
+	count.Count++
+	count.Rows += event.RowsAffected
+	count.Duration += event.Duration
+	if nil != event.Err {
+		count.Errors++
+	}
+	c.counts[key] = count
+}
+
+// Counts returns a copy of the counts by table and operation
+func (c *QueryCounter) Counts() map[QueryKey]QueryCount {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	counts := make(map[QueryKey]QueryCount, len(c.counts))
+	for key, count := range c.counts {
+		counts[key] = count
+	}
+	return counts
+}
+
+// Reset the counts to zero
+func (c *QueryCounter) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.counts = make(map[QueryKey]QueryCount)
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/generator"
+	"github.com/Kasita-Inc/gadget/log"
+)
+
+type recordingHook struct {
+	before []QueryEvent
+	after  []QueryEvent
+}
+
+func (h *recordingHook) BeforeQuery(ctx context.Context, event *QueryEvent) {
+	h.before = append(h.before, *event)
+}
+
+func (h *recordingHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+	h.after = append(h.after, *event)
+}
+
+func TestInstrument(t *testing.T) {
+	assert := assert.New(t)
+	hook := &recordingHook{}
+	db := &Database{}
+	db.AddHook(hook)
+
+	expected := fmt.Errorf("failed")
+	err := db.instrument(context.Background(), "widget", Update, "UPDATE widget", []interface{}{1},
+		func() (int64, error) {
+			if assert.Len(hook.before, 1) {
+				assert.Equal(QueryEvent{Table: "widget", Operation: Update, SQL: "UPDATE widget",
+					Args: []interface{}{1}}, hook.before[0])
+			}
+			assert.Empty(hook.after)
+			time.Sleep(time.Millisecond)
+			return 3, expected
+		})
+	assert.Equal(expected, err)
+	if assert.Len(hook.after, 1) {
+		assert.Equal(int64(3), hook.after[0].RowsAffected)
+		assert.Equal(expected, hook.after[0].Err)
+		assert.True(hook.after[0].Duration >= time.Millisecond)
+	}
+}
+
+func TestSlowQueryLogger(t *testing.T) {
+	assert := assert.New(t)
+	logger := log.NewStackLogger()
+	slow := NewSlowQueryLogger(logger, 10*time.Millisecond)
+
+	slow.AfterQuery(context.Background(), &QueryEvent{Table: "widget", Operation: Select, SQL: "SELECT 1",
+		Duration: time.Millisecond})
+	assert.True(logger.IsEmpty())
+
+	slow.AfterQuery(context.Background(), &QueryEvent{Table: "widget", Operation: Select, SQL: "SELECT 1",
+		Duration: time.Second})
+	message, err := logger.Pop()
+	assert.NoError(err)
+	assert.Equal("slow query (1s) SELECT widget: SELECT 1", message)
+}
+
+func TestQueryCounter(t *testing.T) {
+	assert := assert.New(t)
+	counter := NewQueryCounter()
+	ctx := context.Background()
+	counter.AfterQuery(ctx, &QueryEvent{Table: "widget", Operation: Select, RowsAffected: 2, Duration: time.Second})
+	counter.AfterQuery(ctx, &QueryEvent{Table: "widget", Operation: Select, Err: fmt.Errorf("failed"),
+		Duration: time.Second})
+	counter.AfterQuery(ctx, &QueryEvent{Table: "widget", Operation: Insert, RowsAffected: 1})
+
+	counts := counter.Counts()
+	assert.Equal(QueryCount{Count: 2, Errors: 1, Rows: 2, Duration: 2 * time.Second},
+		counts[QueryKey{Table: "widget", Operation: Select}])
+	assert.Equal(QueryCount{Count: 1, Rows: 1}, counts[QueryKey{Table: "widget", Operation: Insert}])
+
+	counter.Reset()
+	assert.Empty(counter.Counts())
+}
+
+func TestQueryHooks(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+	counter := NewQueryCounter()
+	spec.DB.AddHook(counter)
+
+	record := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.Create(record))
+	record.Place.String, record.Place.Valid = "here", true
+	assert.NoError(spec.DB.Update(record))
+	assert.NoError(spec.DB.ListWhere(&TestRecord{}, &[]TestRecord{}, TestMeta.Name.Equal(record.Name)))
+	assert.NoError(spec.DB.Delete(record))
+
+	counts := counter.Counts()
+	assert.Equal(int64(1), counts[QueryKey{Table: "test_record", Operation: Insert}].Rows)
+	assert.Equal(int64(1), counts[QueryKey{Table: "test_record", Operation: Update}].Rows)
+	assert.Equal(int64(1), counts[QueryKey{Table: "test_record", Operation: Delete}].Rows)
+	// the create and update read the record back
+	assert.Equal(int64(3), counts[QueryKey{Table: "test_record", Operation: Select}].Count)
+}
+
+func TestQueryHooksSavepoints(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+	hook := &recordingHook{}
+	spec.DB.AddHook(hook)
+
+	failed := fmt.Errorf("failed")
+	assert.NoError(spec.DB.InTx(func(tx *sqlx.Tx) error {
+		assert.NoError(spec.DB.NestedTx(tx, func(tx *sqlx.Tx) error { return nil }))
+		assert.Error(spec.DB.NestedTx(tx, func(tx *sqlx.Tx) error { return failed }))
+		return nil
+	}))
+	statements := []string{}
+	for _, event := range hook.after {
+		assert.Equal(SQLQueryType(Transaction), event.Operation)
+		assert.NoError(event.Err)
+		statements = append(statements, strings.SplitN(event.SQL, " sp_", 2)[0])
+	}
+	assert.Equal([]string{"SAVEPOINT", "RELEASE SAVEPOINT", "SAVEPOINT", "ROLLBACK TO SAVEPOINT"}, statements)
+}
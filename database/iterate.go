@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Kasita-Inc/gadget/database/qb"
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+// ErrStopIteration is returned by the function passed to Iterate to stop iterating without an error
+var ErrStopIteration = errors.New("stop iteration")
+
+// RowFunc is called by Iterate after each row is scanned into the target, it must not use the transaction of the
+// iteration since the rows are still being read from the connection
+type RowFunc func() error
+
+// Iterate executes the query and scans the rows into target, a pointer to a struct, one at a time calling fn after
+// each row. Unlike Select only a single row is held in memory, copy the target in fn to retain it. Returning
+// ErrStopIteration from fn stops the iteration without an error, any other error stops the iteration and is returned.
+// The rows are read in a transaction on a replica when configured, the rows and the transaction are always closed.
+//
+//	record := &Order{}
+//	db.Iterate(query, record, func() error {
+//		return encoder.Encode(record)
+//	})
+func (db *Database) Iterate(query *qb.SelectQuery, target interface{}, fn RowFunc) errors.TracerError {
+	return db.IterateContext(context.Background(), query, target, fn)
+}
+
+// IterateContext executes the query and scans the rows into target one at a time, the query is cancelled with the
+// context
+func (db *Database) IterateContext(ctx context.Context, query *qb.SelectQuery, target interface{},
+	fn RowFunc) errors.TracerError {
+	tx, err := db.reader().BeginTxx(ctx, nil)
+	if nil != err {
+		return TranslateError(err, Transaction, "BEGIN", db.Logger)
+	}
+	defer func() {
+		if p := recover(); nil != p {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	if tracerErr := db.iterate(ctx, tx, query, target, fn); nil != tracerErr {
+		tx.Rollback()
+		return tracerErr
+	}
+	if err = tx.Commit(); nil != err {
+		return TranslateError(err, Transaction, "COMMIT", db.Logger)
+	}
+	return nil
+}
+
+// IterateTx executes the query and scans the rows into target one at a time using the transaction, the rows are
+// always closed but the transaction is left open
+func (db *Database) IterateTx(tx *sqlx.Tx, query *qb.SelectQuery, target interface{}, fn RowFunc) errors.TracerError {
+	return db.IterateTxContext(context.Background(), tx, query, target, fn)
+}
+
+// IterateTxContext executes the query and scans the rows into target one at a time using the transaction, the query is
+// cancelled with the context
+func (db *Database) IterateTxContext(ctx context.Context, tx *sqlx.Tx, query *qb.SelectQuery, target interface{},
+	fn RowFunc) errors.TracerError {
+	return db.iterate(ctx, tx, query, target, fn)
+}
+
+func (db *Database) iterate(ctx context.Context, queryer sqlx.QueryerContext, query *qb.SelectQuery,
+	target interface{}, fn RowFunc) errors.TracerError {
+	value := reflect.ValueOf(target)
+	if reflect.Ptr != value.Kind() || value.IsNil() || reflect.Struct != value.Elem().Kind() {
+		return NewNotAPointerError()
+	}
+	stmt, values, err := db.selectSQL(query, qb.NoLimit, 0)
+	if nil != err {
+		return errors.Wrap(err)
+	}
+	table := ""
+	if nil != query.Table() {
+		table = query.Table().GetName()
+	}
+
+	// errors returned by fn are kept apart from those of the driver so that they are not translated
+	var fnErr error
+	err = db.instrument(ctx, table, Select, stmt, values, func() (int64, error) {
+		rows, err := queryer.QueryxContext(ctx, stmt, values...)
+		if nil != err {
+			return 0, err
+		}
+		defer rows.Close()
+		var count int64
+		for rows.Next() {
+			if err = rows.StructScan(target); nil != err {
+				return count, err
+			}
+			count++
+			db.snapshot(target)
+			if fnErr = fn(); nil != fnErr {
+				return count, nil
+			}
+		}
+		return count, rows.Err()
+	})
+	if nil != err {
+		return TranslateError(err, Select, stmt, db.Logger)
+	}
+	if nil != fnErr && ErrStopIteration != fnErr {
+		return errors.Wrap(fnErr)
+	}
+	return nil
+}
+
+// IterateKeyset reads the Records matching the condition in pages of pageSize using keyset pagination, ordered by the
+// SortBy of the Record and then its primary key. Each Record is copied into target, which also determines the table,
+// before fn is called. Each page is a separate query so no transaction or connection is held between pages, rows that
+// are written while iterating may or may not be read. Returning ErrStopIteration from fn stops the iteration without an
+// error.
+func (db *Database) IterateKeyset(target Record, condition *qb.ConditionExpression, pageSize uint,
+	fn RowFunc) errors.TracerError {
+	return db.IterateKeysetContext(context.Background(), target, condition, pageSize, fn)
+}
+
+// IterateKeysetContext reads the Records matching the condition in pages of pageSize using keyset pagination, the query
+// for each page is cancelled with the context
+func (db *Database) IterateKeysetContext(ctx context.Context, target Record, condition *qb.ConditionExpression,
+	pageSize uint, fn RowFunc) errors.TracerError {
+	value := reflect.ValueOf(target)
+	if reflect.Ptr != value.Kind() || value.IsNil() || reflect.Struct != value.Elem().Kind() {
+		return NewNotAPointerError()
+	}
+	options := NewCursorOptions(pageSize, "")
+	for {
+		page := reflect.New(reflect.SliceOf(value.Elem().Type()))
+		cursor, err := db.ListWhereCursorContext(ctx, target, page.Interface(), condition, options)
+		if nil != err {
+			return err
+		}
+		for i := 0; i < page.Elem().Len(); i++ {
+			value.Elem().Set(page.Elem().Index(i))
+			if err := fn(); nil != err {
+				if ErrStopIteration == err {
+					return nil
+				}
+				return errors.Wrap(err)
+			}
+		}
+		if "" == cursor {
+			return nil
+		}
+		options.Cursor = cursor
+	}
+}
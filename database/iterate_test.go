@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/database/qb"
+	"github.com/Kasita-Inc/gadget/generator"
+)
+
+func TestIterate(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	place := sql.NullString{String: generator.String(20), Valid: true}
+	expected := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		record := &TestRecord{Name: generator.Name(), Place: place}
+		assert.NoError(spec.DB.Create(record))
+		expected[record.ID] = true
+	}
+	query := qb.Select(TestMeta.AllColumns()).From(TestMeta).Where(TestMeta.Place.Equal(place.String))
+
+	actual := map[string]bool{}
+	record := &TestRecord{}
+	assert.NoError(spec.DB.Iterate(query, record, func() error {
+		actual[record.ID] = true
+		return nil
+	}))
+	assert.Equal(expected, actual)
+
+	// the query is rendered for the database without changing the dialect set on it
+	stmt, _, err := query.SQL(qb.NoLimit, 0)
+	assert.NoError(err)
+	mysql, _, _ := query.DialectSQL(qb.MySQL, qb.NoLimit, 0)
+	assert.Equal(mysql, stmt)
+
+	count := 0
+	assert.NoError(spec.DB.Iterate(query, record, func() error {
+		count++
+		return ErrStopIteration
+	}))
+	assert.Equal(1, count)
+
+	failed := fmt.Errorf("failed")
+	assert.EqualError(spec.DB.Iterate(query, record, func() error { return failed }), "failed")
+	assert.IsType(&NotAPointerError{}, spec.DB.Iterate(query, TestRecord{}, func() error { return nil }))
+
+	// the connection is released after stopping early so that the next query can use it
+	spec.DB.SetMaxOpenConns(1)
+	defer spec.DB.SetMaxOpenConns(0)
+	assert.NoError(spec.DB.Iterate(query, record, func() error { return ErrStopIteration }))
+	assert.NoError(spec.DB.Read(&TestRecord{}, record.PrimaryKey()))
+
+	// and after fn panics, a leaked transaction would hold the connection until the read times out
+	assert.Panics(func() {
+		spec.DB.Iterate(query, record, func() error { panic("failed") })
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(spec.DB.ReadContext(ctx, &TestRecord{}, record.PrimaryKey()))
+}
+
+func TestIterateKeyset(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	place := sql.NullString{String: generator.String(20), Valid: true}
+	expected := map[string]bool{}
+	for i := 0; i < 7; i++ {
+		record := &TestRecord{Name: generator.Name(), Place: place}
+		assert.NoError(spec.DB.Create(record))
+		expected[record.ID] = true
+	}
+
+	actual := map[string]bool{}
+	record := &TestRecord{}
+	assert.NoError(spec.DB.IterateKeyset(record, TestMeta.Place.Equal(place.String), 3, func() error {
+		actual[record.ID] = true
+		return nil
+	}))
+	assert.Equal(expected, actual)
+
+	count := 0
+	assert.NoError(spec.DB.IterateKeyset(record, TestMeta.Place.Equal(place.String), 3, func() error {
+		count++
+		if 4 == count {
+			return ErrStopIteration
+		}
+		return nil
+	}))
+	assert.Equal(4, count)
+
+	// the context cancels the query of the page being read
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	count = 0
+	assert.Error(spec.DB.IterateKeysetContext(ctx, record, TestMeta.Place.Equal(place.String), 3, func() error {
+		count++
+		if 3 == count {
+			cancel()
+		}
+		return nil
+	}))
+	assert.Equal(3, count)
+}
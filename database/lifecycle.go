@@ -0,0 +1,99 @@
+package database
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+// BeforeCreator is a Record that is called before it is inserted by CreateTx, UpsertTx or their batch variants
+type BeforeCreator interface {
+	BeforeCreate(tx *sqlx.Tx) error
+}
+
+// AfterCreator is a Record that is called after it is inserted and read back by CreateTx, UpsertTx or their batch
+// variants
+type AfterCreator interface {
+	AfterCreate(tx *sqlx.Tx) error
+}
+
+// BeforeUpdater is a Record that is called before it is updated by UpdateTx
+type BeforeUpdater interface {
+	BeforeUpdate(tx *sqlx.Tx) error
+}
+
+// AfterUpdater is a Record that is called after it is updated and read back by UpdateTx
+type AfterUpdater interface {
+	AfterUpdate(tx *sqlx.Tx) error
+}
+
+// BeforeDeleter is a Record that is called before it is deleted by DeleteTx
+type BeforeDeleter interface {
+	BeforeDelete(tx *sqlx.Tx) error
+}
+
+// Validator is a Record that is validated before it is written by CreateTx, UpsertTx, their batch variants or
+// UpdateTx, after any before hook has run. The error is returned as a ValidationError.
+type Validator interface {
+	Validate() error
+}
+
+// beforeCreate runs the BeforeCreate hook and validation of the Record
+func beforeCreate(obj Record, tx *sqlx.Tx) errors.TracerError {
+	if hook, ok := obj.(BeforeCreator); ok {
+		if err := hook.BeforeCreate(tx); nil != err {
+			return errors.Wrap(err)
+		}
+	}
+	return validate(obj)
+}
+
+// afterCreate runs the AfterCreate hook of the Record
+func afterCreate(obj Record, tx *sqlx.Tx) errors.TracerError {
+	if hook, ok := obj.(AfterCreator); ok {
+		return errors.Wrap(hook.AfterCreate(tx))
+	}
+	return nil
+}
+
+// beforeUpdate runs the BeforeUpdate hook and validation of the Record
+func beforeUpdate(obj Record, tx *sqlx.Tx) errors.TracerError {
+	if hook, ok := obj.(BeforeUpdater); ok {
+		if err := hook.BeforeUpdate(tx); nil != err {
+			return errors.Wrap(err)
+		}
+	}
+	return validate(obj)
+}
+
+// afterUpdate runs the AfterUpdate hook of the Record
+func afterUpdate(obj Record, tx *sqlx.Tx) errors.TracerError {
+	if hook, ok := obj.(AfterUpdater); ok {
+		return errors.Wrap(hook.AfterUpdate(tx))
+	}
+	return nil
+}
+
+// beforeDelete runs the BeforeDelete hook of the Record
+func beforeDelete(obj Record, tx *sqlx.Tx) errors.TracerError {
+	if hook, ok := obj.(BeforeDeleter); ok {
+		return errors.Wrap(hook.BeforeDelete(tx))
+	}
+	return nil
+}
+
+// validate returns a ValidationError when the Record is a Validator and is not valid
+func validate(obj Record) errors.TracerError {
+	validator, ok := obj.(Validator)
+	if !ok {
+		return nil
+	}
+	err := validator.Validate()
+	if nil == err {
+		return nil
+	}
+	if validationErr, ok := err.(*ValidationError); ok {
+		return validationErr
+	}
+	return NewValidationError("%s", err.Error())
+}
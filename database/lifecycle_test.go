@@ -0,0 +1,102 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/generator"
+)
+
+type hookedRecord struct {
+	TestRecord
+	calls   []string
+	invalid bool
+	fail    string
+}
+
+func (record *hookedRecord) call(name string) error {
+	record.calls = append(record.calls, name)
+	if name == record.fail {
+		return fmt.Errorf("%s failed", name)
+	}
+	return nil
+}
+
+func (record *hookedRecord) BeforeCreate(tx *sqlx.Tx) error {
+	return record.call("BeforeCreate")
+}
+
+func (record *hookedRecord) AfterCreate(tx *sqlx.Tx) error {
+	return record.call("AfterCreate")
+}
+
+func (record *hookedRecord) BeforeUpdate(tx *sqlx.Tx) error {
+	return record.call("BeforeUpdate")
+}
+
+func (record *hookedRecord) AfterUpdate(tx *sqlx.Tx) error {
+	return record.call("AfterUpdate")
+}
+
+func (record *hookedRecord) BeforeDelete(tx *sqlx.Tx) error {
+	return record.call("BeforeDelete")
+}
+
+func (record *hookedRecord) Validate() error {
+	record.calls = append(record.calls, "Validate")
+	if record.invalid {
+		return fmt.Errorf("name is invalid")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+	assert.NoError(validate(&TestRecord{}))
+	assert.NoError(validate(&hookedRecord{}))
+
+	err := validate(&hookedRecord{invalid: true})
+	assert.IsType(&ValidationError{}, err)
+	assert.EqualError(err, "name is invalid")
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	record := &hookedRecord{TestRecord: TestRecord{Name: generator.Name()}}
+	assert.NoError(spec.DB.Create(record))
+	assert.Equal([]string{"BeforeCreate", "Validate", "AfterCreate"}, record.calls)
+
+	record.calls = nil
+	assert.NoError(spec.DB.Update(record))
+	assert.Equal([]string{"BeforeUpdate", "Validate", "AfterUpdate"}, record.calls)
+
+	record.calls = nil
+	assert.NoError(spec.DB.Delete(record))
+	assert.Equal([]string{"BeforeDelete"}, record.calls)
+	assert.IsType(&NotFoundError{}, spec.DB.Read(&TestRecord{}, record.PrimaryKey()))
+}
+
+func TestLifecycleHookFailures(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	invalid := &hookedRecord{TestRecord: TestRecord{Name: generator.Name()}, invalid: true}
+	assert.IsType(&ValidationError{}, spec.DB.Create(invalid))
+	assert.Equal([]string{"BeforeCreate", "Validate"}, invalid.calls)
+	assert.IsType(&NotFoundError{}, spec.DB.Read(&TestRecord{}, invalid.PrimaryKey()))
+
+	// an error from an after hook rolls back the write
+	failed := &hookedRecord{TestRecord: TestRecord{Name: generator.Name()}, fail: "AfterCreate"}
+	assert.EqualError(spec.DB.Create(failed), "AfterCreate failed")
+	assert.IsType(&NotFoundError{}, spec.DB.Read(&TestRecord{}, failed.PrimaryKey()))
+
+	record := &hookedRecord{TestRecord: TestRecord{Name: generator.Name()}, fail: "BeforeDelete"}
+	assert.NoError(spec.DB.Create(record))
+	assert.EqualError(spec.DB.Delete(record), "BeforeDelete failed")
+	assert.NoError(spec.DB.Read(&TestRecord{}, record.PrimaryKey()))
+}
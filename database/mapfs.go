@@ -0,0 +1,105 @@
+package database
+
+import (
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mapFS is a flat fs.FS of files keyed by name, it is used instead of fstest.MapFS so that the testing package is not
+// linked into programs that use migrations
+type mapFS map[string]string
+
+func (m mapFS) Open(name string) (fs.File, error) {
+	if "." == name {
+		return &mapFile{info: mapFileInfo{name: ".", mode: fs.ModeDir | 0755}, entries: m.entries()}, nil
+	}
+	data, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &mapFile{info: mapFileInfo{name: name, size: int64(len(data)), mode: 0644},
+		reader: strings.NewReader(data)}, nil
+}
+
+func (m mapFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if "." != name {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return m.entries(), nil
+}
+
+func (m mapFS) ReadFile(name string) ([]byte, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+	}
+	return []byte(data), nil
+}
+
+// entries of the files sorted by name
+func (m mapFS) entries() []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(m))
+	for name, data := range m {
+		entries = append(entries, fs.FileInfoToDirEntry(mapFileInfo{name: name, size: int64(len(data)), mode: 0644}))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries
+}
+
+// mapFile is an open file or the root directory of a mapFS
+type mapFile struct {
+	info    mapFileInfo
+	reader  *strings.Reader
+	entries []fs.DirEntry
+}
+
+func (f *mapFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *mapFile) Read(b []byte) (int, error) {
+	if nil == f.reader {
+		return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: fs.ErrInvalid}
+	}
+	return f.reader.Read(b)
+}
+
+func (f *mapFile) Close() error {
+	return nil
+}
+
+func (f *mapFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.info.name, Err: fs.ErrInvalid}
+	}
+	if n <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(f.entries) {
+		n = len(f.entries)
+	}
+	entries := f.entries[:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
+
+type mapFileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (info mapFileInfo) Name() string       { return info.name }
+func (info mapFileInfo) Size() int64        { return info.size }
+func (info mapFileInfo) Mode() fs.FileMode  { return info.mode }
+func (info mapFileInfo) ModTime() time.Time { return time.Time{} }
+func (info mapFileInfo) IsDir() bool        { return info.mode.IsDir() }
+func (info mapFileInfo) Sys() interface{}   { return nil }
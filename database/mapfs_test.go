@@ -0,0 +1,24 @@
+package database
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapFS(t *testing.T) {
+	assert := assert.New(t)
+	source := mapFS{"0001_first.up.sql": "CREATE TABLE first (id int);", "README.md": ""}
+	assert.NoError(fstest.TestFS(source, "0001_first.up.sql", "README.md"))
+
+	data, err := fs.ReadFile(source, "0001_first.up.sql")
+	assert.NoError(err)
+	assert.Equal("CREATE TABLE first (id int);", string(data))
+	_, err = source.Open("missing.sql")
+	assert.True(errors.Is(err, fs.ErrNotExist))
+	_, err = fs.ReadDir(source, "missing")
+	assert.True(errors.Is(err, fs.ErrNotExist))
+}
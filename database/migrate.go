@@ -2,20 +2,280 @@ package database
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
 	"os"
 	"path"
+	"regexp"
+	"sort"
+	"strconv"
 
 	"github.com/mattes/migrate"
 	_ "github.com/mattes/migrate/database/mysql" // imported for side effect as driver for mysql
 	_ "github.com/mattes/migrate/source/file"    // imported for side effect as driver for migrate
 
+	"github.com/Kasita-Inc/gadget/errors"
 	"github.com/Kasita-Inc/gadget/fileutil"
 	"github.com/Kasita-Inc/gadget/generator"
 	"github.com/Kasita-Inc/gadget/log"
 )
 
-// generateSQLFiles writes temporary files from the migration map
-func generateSQLFiles(migrations map[string]string) (string, error) {
+// migrationFile matches the file names read by the migrate file source, {version}_{title}.{up|down}.{extension}
+var migrationFile = regexp.MustCompile(`^([0-9]+)_(.*)\.(down|up)\.(.*)$`)
+
+// Migrator applies versioned migrations from an fs.FS to a database
+type Migrator struct {
+	source fs.FS
+	dir    string
+	dbURL  string
+	// DryRun prints the SQL of the migrations that would be applied to Output instead of applying them
+	DryRun bool
+	// Output receives the SQL printed by a dry run, defaults to os.Stdout
+	Output io.Writer
+}
+
+// MigrationStatus describes the migrations applied to a database
+type MigrationStatus struct {
+	// Version of the last migration applied, 0 when no migrations have been applied
+	Version uint
+	// Dirty is true when the last migration failed and the database must be fixed manually
+	Dirty bool
+	// Pending are the versions of the up migrations that have not been applied in order
+	Pending []uint
+	applied bool
+}
+
+// migrationScripts are the file names of the up and down migrations by version
+type migrationScripts struct {
+	versions []uint
+	up       map[uint]string
+	down     map[uint]string
+}
+
+// NewMigrator for the migrations in the directory of the source, use "." for the root of the source. The directory of
+// an embed.FS is the path it was embedded with.
+func NewMigrator(source fs.FS, dir string, dbURL string) *Migrator {
+	return &Migrator{
+		source: source,
+		dir:    dir,
+		dbURL:  dbURL,
+		Output: os.Stdout,
+	}
+}
+
+// NewMapMigrator for migrations keyed by file name
+func NewMapMigrator(migrations map[string]string, dbURL string) *Migrator {
+	source := mapFS{}
+	for filename, data := range migrations {
+		source[filename] = data
+	}
+	return NewMigrator(source, ".", dbURL)
+}
+
+// Up applies all of the pending migrations
+func (m *Migrator) Up() errors.TracerError {
+	return m.apply(func(scripts *migrationScripts, status *MigrationStatus) []string {
+		return scripts.upTo(status, -1, nil)
+	}, func(instance *migrate.Migrate) error {
+		return instance.Up()
+	})
+}
+
+// Down rolls back all of the applied migrations
+func (m *Migrator) Down() errors.TracerError {
+	return m.apply(func(scripts *migrationScripts, status *MigrationStatus) []string {
+		return scripts.downTo(status, -1, nil)
+	}, func(instance *migrate.Migrate) error {
+		return instance.Down()
+	})
+}
+
+// Steps applies the next n migrations when n is positive and rolls back the last n migrations when n is negative
+func (m *Migrator) Steps(n int) errors.TracerError {
+	if 0 == n {
+		return nil
+	}
+	return m.apply(func(scripts *migrationScripts, status *MigrationStatus) []string {
+		if n > 0 {
+			return scripts.upTo(status, n, nil)
+		}
+		return scripts.downTo(status, -n, nil)
+	}, func(instance *migrate.Migrate) error {
+		return instance.Steps(n)
+	})
+}
+
+// Goto applies or rolls back migrations until the database is at the passed version
+func (m *Migrator) Goto(version uint) errors.TracerError {
+	scripts, err := m.scripts()
+	if nil != err {
+		return err
+	}
+	if _, ok := scripts.up[version]; !ok {
+		return NewValidationError("migration version %d does not exist", version)
+	}
+	return m.apply(func(scripts *migrationScripts, status *MigrationStatus) []string {
+		if !status.applied || version > status.Version {
+			return scripts.upTo(status, -1, func(v uint) bool { return v <= version })
+		}
+		return scripts.downTo(status, -1, func(v uint) bool { return v > version })
+	}, func(instance *migrate.Migrate) error {
+		return instance.Migrate(version)
+	})
+}
+
+// Status returns the current version of the database and the migrations that have not been applied
+func (m *Migrator) Status() (*MigrationStatus, errors.TracerError) {
+	scripts, tracerErr := m.scripts()
+	if nil != tracerErr {
+		return nil, tracerErr
+	}
+	status := &MigrationStatus{Pending: []uint{}}
+	tracerErr = m.run(func(instance *migrate.Migrate) error {
+		version, dirty, err := instance.Version()
+		if migrate.ErrNilVersion == err {
+			return nil
+		}
+		status.Version, status.Dirty, status.applied = version, dirty, true
+		return err
+	})
+	if nil != tracerErr {
+		return nil, tracerErr
+	}
+	for _, version := range scripts.versions {
+		if _, ok := scripts.up[version]; ok && (!status.applied || version > status.Version) {
+			status.Pending = append(status.Pending, version)
+		}
+	}
+	return status, nil
+}
+
+// apply runs the migration, or prints the SQL of the scripts in the plan on a dry run
+func (m *Migrator) apply(plan func(*migrationScripts, *MigrationStatus) []string,
+	migration func(*migrate.Migrate) error) errors.TracerError {
+	if !m.DryRun {
+		return m.run(migration)
+	}
+	scripts, err := m.scripts()
+	if nil != err {
+		return err
+	}
+	status, err := m.Status()
+	if nil != err {
+		return err
+	}
+	if status.Dirty {
+		return NewMigrationError(fmt.Errorf("database version %d is dirty", status.Version))
+	}
+	for _, filename := range plan(scripts, status) {
+		data, err := fs.ReadFile(m.source, path.Join(m.dir, filename))
+		if nil != err {
+			return errors.Wrap(err)
+		}
+		if _, err = fmt.Fprintf(m.Output, "-- %s\n%s\n", filename, data); nil != err {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// run writes the migrations to a temporary directory for the duration of the call to fn
+func (m *Migrator) run(fn func(*migrate.Migrate) error) errors.TracerError {
+	basepath, err := writeMigrationFiles(m.source, m.dir)
+	if nil != err {
+		return errors.Wrap(err)
+	}
+	defer os.RemoveAll(basepath)
+	instance, err := migrate.New(fmt.Sprintf("file://%s", basepath), m.dbURL)
+	if nil != err {
+		return NewMigrationError(err)
+	}
+	defer instance.Close()
+	if err = fn(instance); nil != err && migrate.ErrNoChange != err {
+		return NewMigrationError(err)
+	}
+	return nil
+}
+
+// scripts reads the names of the migrations from the source
+func (m *Migrator) scripts() (*migrationScripts, errors.TracerError) {
+	entries, err := fs.ReadDir(m.source, m.dir)
+	if nil != err {
+		return nil, errors.Wrap(err)
+	}
+	scripts := &migrationScripts{up: map[uint]string{}, down: map[uint]string{}}
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || nil == match {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if nil != err {
+			return nil, NewValidationError("invalid migration version in %s", entry.Name())
+		}
+		v := uint(version)
+		if _, up := scripts.up[v]; !up {
+			if _, down := scripts.down[v]; !down {
+				scripts.versions = append(scripts.versions, v)
+			}
+		}
+		if "up" == match[3] {
+			scripts.up[v] = entry.Name()
+		} else {
+			scripts.down[v] = entry.Name()
+		}
+	}
+	sort.Slice(scripts.versions, func(i, j int) bool { return scripts.versions[i] < scripts.versions[j] })
+	return scripts, nil
+}
+
+// upTo returns up to limit (all when negative) up migrations after the status that match the filter in order
+func (scripts *migrationScripts) upTo(status *MigrationStatus, limit int, filter func(uint) bool) []string {
+	files := []string{}
+	for _, version := range scripts.versions {
+		if status.applied && version <= status.Version {
+			continue
+		}
+		if nil != filter && !filter(version) {
+			break
+		}
+		if limit >= 0 && len(files) == limit {
+			break
+		}
+		if filename, ok := scripts.up[version]; ok {
+			files = append(files, filename)
+		}
+	}
+	return files
+}
+
+// downTo returns up to limit (all when negative) down migrations from the status that match the filter in order
+func (scripts *migrationScripts) downTo(status *MigrationStatus, limit int, filter func(uint) bool) []string {
+	files := []string{}
+	if !status.applied {
+		return files
+	}
+	for i := len(scripts.versions) - 1; i >= 0; i-- {
+		version := scripts.versions[i]
+		if version > status.Version {
+			continue
+		}
+		if nil != filter && !filter(version) {
+			break
+		}
+		if limit >= 0 && len(files) == limit {
+			break
+		}
+		if filename, ok := scripts.down[version]; ok {
+			files = append(files, filename)
+		}
+	}
+	return files
+}
+
+// writeMigrationFiles copies the files in the directory of the source to a new temporary directory
+func writeMigrationFiles(source fs.FS, dir string) (string, error) {
 	basepath := path.Join(os.TempDir(), "db", generator.ID("dbm"))
 	_, err := fileutil.EnsureDir(basepath, 0777)
 
@@ -23,61 +283,49 @@ func generateSQLFiles(migrations map[string]string) (string, error) {
 		return "", fmt.Errorf("Unable to create directory %s\n%s", basepath, err)
 	}
 
-	for filename, data := range migrations {
-		f, err := os.Create(path.Join(basepath, filename))
+	entries, err := fs.ReadDir(source, dir)
+	if nil != err {
+		os.RemoveAll(basepath)
+		return "", fmt.Errorf("Unable to read migrations from %s\n%s", dir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(source, path.Join(dir, entry.Name()))
+		if nil == err {
+			err = ioutil.WriteFile(path.Join(basepath, entry.Name()), data, 0644)
+		}
 		if nil != err {
-			return "", fmt.Errorf("Unable to write %s to %s for database migrations\n%s", filename, basepath, err)
+			os.RemoveAll(basepath)
+			return "", fmt.Errorf("Unable to write %s to %s for database migrations\n%s", entry.Name(), basepath, err)
 		}
-		defer f.Close()
-		f.WriteString(data)
 	}
+	return basepath, nil
+}
 
+// generateSQLFiles writes temporary files from the migration map
+func generateSQLFiles(migrations map[string]string) (string, error) {
+	basepath, err := writeMigrationFiles(NewMapMigrator(migrations, "").source, ".")
+	if nil != err {
+		return "", err
+	}
 	return fmt.Sprintf("file://%s", basepath), nil
 }
 
 // Migrate ensures that the database is up to date
-// Panics on error since this is an unrecoverable, fatal issue
+// Panics on error since this is an unrecoverable, fatal issue, use a Migrator to handle the error instead
 func Migrate(migrations map[string]string, dbURL string) {
-	sqlFilesPath, err := generateSQLFiles(migrations)
-	if err != nil {
+	if err := NewMapMigrator(migrations, dbURL).Up(); nil != err {
 		panic(log.Fatal(err))
 	}
-	m, err := migrate.New(sqlFilesPath, dbURL)
-	if err != nil {
-		msg := fmt.Sprintf("couldn't load migration scripts from %s, %s (%s)", sqlFilesPath, dbURL, err)
-		log.Fatalf(msg)
-		panic(msg)
-	}
-	defer m.Close()
-
-	// Migrate all the way up ...
-	if err := m.Up(); err != nil && "no change" != err.Error() {
-		msg := fmt.Sprintf("Migration ERROR: %#v", err)
-		log.Fatalf(msg)
-		panic(msg)
-	}
 }
 
 // Reset runs all rollback migrations for the database
-// Panics on error since this is an unrecoverable, fatal issue
+// Panics on error since this is an unrecoverable, fatal issue, use a Migrator to handle the error instead
 // This will essentially nuke your database.  Only really useful for test scenario cleanup.
 func Reset(migrations map[string]string, dbURL string) {
-	sqlFilesPath, err := generateSQLFiles(migrations)
-	if err != nil {
-		panic(log.Error(err))
-	}
-	m, err := migrate.New(sqlFilesPath, dbURL)
-	if err != nil {
-		msg := fmt.Sprintf("couldn't load migration scripts from %s (%s)", sqlFilesPath, err)
-		log.Fatalf(msg)
-		panic(msg)
-	}
-	defer m.Close()
-
-	// Migrate all the way down ...
-	if err := m.Down(); err != nil {
-		msg := fmt.Sprintf("Rollback ERROR: %#v", err)
-		log.Fatalf(msg)
-		panic(msg)
+	if err := NewMapMigrator(migrations, dbURL).Down(); nil != err {
+		panic(log.Fatal(err))
 	}
 }
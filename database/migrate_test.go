@@ -1,6 +1,7 @@
 package database
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path"
@@ -46,6 +47,7 @@ func TestGenerateSqlFiles(t *testing.T) {
 }
 
 func TestMigrateAndResetErrors(t *testing.T) {
+	skipUnlessMySQL(t)
 	assert := assert.New(t)
 	migrations := make(map[string]string)
 	migrations["0002.up.sql"] = `CREATE TABLE test_create (
@@ -68,3 +70,96 @@ func TestMigrateAndResetErrors(t *testing.T) {
 	assert.Panics(func() { Migrate(migrations, config.DatabaseDialectURL()) })
 	assert.Panics(func() { Reset(migrations, config.DatabaseDialectURL()) })
 }
+
+var migratorMigrations = map[string]string{
+	"0001_first.up.sql":    "CREATE TABLE test_migrator_first (id varchar(128) primary key);",
+	"0001_first.down.sql":  "DROP TABLE IF EXISTS test_migrator_first;",
+	"0002_second.up.sql":   "CREATE TABLE test_migrator_second (id varchar(128) primary key);",
+	"0002_second.down.sql": "DROP TABLE IF EXISTS test_migrator_second;",
+	"0003_third.up.sql":    "CREATE TABLE test_migrator_third (id varchar(128) primary key);",
+	"README.md":            "not a migration",
+}
+
+func TestMigrationScripts(t *testing.T) {
+	assert := assert.New(t)
+	scripts, err := NewMapMigrator(migratorMigrations, "").scripts()
+	assert.NoError(err)
+	assert.Equal([]uint{1, 2, 3}, scripts.versions)
+
+	none := &MigrationStatus{}
+	assert.Equal([]string{"0001_first.up.sql", "0002_second.up.sql", "0003_third.up.sql"}, scripts.upTo(none, -1, nil))
+	assert.Equal([]string{"0001_first.up.sql"}, scripts.upTo(none, 1, nil))
+	assert.Empty(scripts.downTo(none, -1, nil))
+
+	second := &MigrationStatus{Version: 2, applied: true}
+	assert.Equal([]string{"0003_third.up.sql"}, scripts.upTo(second, -1, nil))
+	assert.Equal([]string{"0002_second.down.sql", "0001_first.down.sql"}, scripts.downTo(second, -1, nil))
+	assert.Equal([]string{"0002_second.down.sql"}, scripts.downTo(second, -1, func(v uint) bool { return v > 1 }))
+	assert.Equal([]string{"0002_second.down.sql"}, scripts.downTo(second, 1, nil))
+	assert.Equal([]string{"0001_first.up.sql", "0002_second.up.sql"},
+		scripts.upTo(none, -1, func(v uint) bool { return v <= 2 }))
+}
+
+func TestWriteMigrationFiles(t *testing.T) {
+	assert := assert.New(t)
+	os.RemoveAll(path.Join(os.TempDir(), "db"))
+	basepath, err := writeMigrationFiles(NewMapMigrator(migratorMigrations, "").source, ".")
+	assert.NoError(err)
+	defer os.RemoveAll(basepath)
+	content, err := ioutil.ReadFile(path.Join(basepath, "0002_second.up.sql"))
+	assert.NoError(err)
+	assert.Equal(migratorMigrations["0002_second.up.sql"], string(content))
+
+	_, err = writeMigrationFiles(NewMapMigrator(migratorMigrations, "").source, "missing")
+	assert.Error(err)
+}
+
+func TestMigrator(t *testing.T) {
+	skipUnlessMySQL(t)
+	assert := assert.New(t)
+	config := &specification{
+		DatabaseType: "mysql",
+	}
+	environment.Process(config)
+	separator := "?"
+	if strings.Contains(config.DatabaseDialectURL(), "?") {
+		separator = "&"
+	}
+	// track the versions separately from the migrations run by TestMain
+	migrator := NewMapMigrator(migratorMigrations,
+		config.DatabaseDialectURL()+separator+"x-migrations-table=test_migrator_versions")
+	defer migrator.Down()
+
+	status, err := migrator.Status()
+	assert.NoError(err)
+	assert.Equal(uint(0), status.Version)
+	assert.Equal([]uint{1, 2, 3}, status.Pending)
+
+	output := &bytes.Buffer{}
+	migrator.DryRun = true
+	migrator.Output = output
+	assert.NoError(migrator.Goto(2))
+	assert.Equal("-- 0001_first.up.sql\n"+migratorMigrations["0001_first.up.sql"]+"\n"+
+		"-- 0002_second.up.sql\n"+migratorMigrations["0002_second.up.sql"]+"\n", output.String())
+	status, err = migrator.Status()
+	assert.NoError(err)
+	assert.Equal(uint(0), status.Version)
+
+	migrator.DryRun = false
+	assert.NoError(migrator.Steps(1))
+	status, err = migrator.Status()
+	assert.NoError(err)
+	assert.Equal(uint(1), status.Version)
+	assert.Equal([]uint{2, 3}, status.Pending)
+
+	assert.NoError(migrator.Goto(3))
+	assert.NoError(migrator.Up())
+	assert.NoError(migrator.Steps(-1))
+	status, err = migrator.Status()
+	assert.NoError(err)
+	assert.Equal(uint(2), status.Version)
+	assert.False(status.Dirty)
+
+	assert.IsType(&ValidationError{}, migrator.Goto(7))
+	assert.IsType(&MigrationError{}, NewMapMigrator(migratorMigrations, "").Up())
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"reflect"
 
 	"github.com/jmoiron/sqlx"
@@ -89,16 +90,33 @@ func NewListOptions(limit uint, offset uint) *ListOptions {
 type Database struct {
 	*sqlx.DB
 	Logger log.Logger
-}
-
-// Initialize establishes the database connection
-func Initialize(config Config) *Database {
+	// Dialect used to render queries, defaults to MySQL when nil
+	Dialect qb.Dialect
+	// TxRetry used by InTx, defaults to DefaultTxRetry when nil
+	TxRetry *TxRetry
+	// withDeleted includes soft deleted rows in reads
+	withDeleted bool
+	// replicas receive the reads made outside of a transaction, nil when there are no replicas
+	replicas *replicaSet
+	// primary forces reads to use the primary when there are replicas
+	primary bool
+	// Hooks are called before and after each statement executed by the Database
+	Hooks []QueryHook
+}
+
+// Initialize establishes the database connection. Reads made outside of a transaction are routed to the healthy
+// replicas, if any, using round-robin.
+func Initialize(config Config, replicas ...string) *Database {
 	logger := log.New("Database", log.FunctionFromEnv())
 	conn, err := connect(config.DatabaseDialect(), config.DatabaseConnection(), logger)
 	if nil != err {
 		panic(err)
 	}
-	return &Database{DB: conn, Logger: logger}
+	db := &Database{DB: conn, Logger: logger, Dialect: qb.DialectFor(config.DatabaseDialect())}
+	if len(replicas) > 0 {
+		db.replicas = newReplicaSet(config.DatabaseDialect(), replicas, ReplicaHealthInterval, logger)
+	}
+	return db
 }
 
 func connect(dialect, url string, logger log.Logger) (*sqlx.DB, errors.TracerError) {
@@ -126,12 +144,23 @@ type Model struct {
 
 // Create initializes a Record and inserts it into the Database
 func (db *Database) Create(obj Record) errors.TracerError {
-	tx, err := db.Beginx()
+	return db.CreateContext(context.Background(), obj)
+}
+
+// CreateContext initializes a Record and inserts it into the Database, the insert is cancelled with the context
+func (db *Database) CreateContext(ctx context.Context, obj Record) errors.TracerError {
+	return db.InTxContext(ctx, func(tx *sqlx.Tx) error {
+		return db.CreateTxContext(ctx, obj, tx)
+	})
+}
+
+// BeginContext starts a transaction that is rolled back if the context is cancelled before it is committed
+func (db *Database) BeginContext(ctx context.Context) (*sqlx.Tx, errors.TracerError) {
+	tx, err := db.BeginTxx(ctx, nil)
 	if nil != err {
-		return errors.Wrap(err)
+		return nil, TranslateError(err, Transaction, "BEGIN", db.Logger)
 	}
-	err = db.CreateTx(obj, tx)
-	return CommitOrRollback(tx, err)
+	return tx, nil
 }
 
 func appendIfMissing(slice []qb.TableField, i qb.TableField) []qb.TableField {
@@ -152,20 +181,32 @@ func contains(slice []qb.TableField, i qb.TableField) bool {
 
 // CreateTx initializes a Record and inserts it into the Database
 func (db *Database) CreateTx(obj Record, tx *sqlx.Tx) errors.TracerError {
+	return db.CreateTxContext(context.Background(), obj, tx)
+}
+
+// CreateTxContext initializes a Record and inserts it into the Database using a transaction. The BeforeCreator,
+// Validator and AfterCreator interfaces of the Record are called within the transaction.
+func (db *Database) CreateTxContext(ctx context.Context, obj Record, tx *sqlx.Tx) errors.TracerError {
 	var tracerErr errors.TracerError
 	var previousPK PrimaryKeyValue
 	obj.Initialize()
+	if tracerErr = beforeCreate(obj, tx); nil != tracerErr {
+		return tracerErr
+	}
 	for i := 0; i < 5; i++ {
 		writeCols := appendIfMissing(obj.Meta().WriteColumns(), obj.Meta().PrimaryKey())
-		query := qb.Insert(writeCols...)
+		query := qb.Insert(writeCols...).Dialect(db.Dialect)
 		stmt, err := query.ParameterizedSQL()
 		if nil != err {
 			return errors.Wrap(err)
 		}
 
-		_, err = tx.NamedExec(stmt, obj)
+		_, err = db.namedExecContext(ctx, tx, obj.Meta().GetName(), Insert, stmt, obj)
 		if nil == err {
-			return db.ReadTx(obj, obj.PrimaryKey(), tx)
+			if tracerErr = db.ReadTxContext(ctx, obj, obj.PrimaryKey(), tx); nil != tracerErr {
+				return tracerErr
+			}
+			return afterCreate(obj, tx)
 		}
 		tracerErr = TranslateError(err, Insert, stmt, db.Logger)
 		switch tracerErr.(type) {
@@ -186,62 +227,90 @@ func (db *Database) CreateTx(obj Record, tx *sqlx.Tx) errors.TracerError {
 
 // UpsertTx a new entry into the database for the Record
 func (db *Database) UpsertTx(obj Record, tx *sqlx.Tx) errors.TracerError {
-	insertCols := appendIfMissing(obj.Meta().ReadColumns(), obj.Meta().PrimaryKey())
-	updateCols := make([]qb.TableField, len(obj.Meta().WriteColumns()))
-	copy(updateCols, obj.Meta().WriteColumns())
-	createdOn := qb.TableField{Name: "created_on", Table: obj.Meta().GetName()}
-	if contains(obj.Meta().ReadColumns(), createdOn) {
-		updateCols = appendIfMissing(updateCols, createdOn)
-	}
-	updateOn := qb.TableField{Name: "updated_on", Table: obj.Meta().GetName()}
-	if contains(obj.Meta().ReadColumns(), updateOn) {
-		updateCols = appendIfMissing(updateCols, updateOn)
-	}
+	return db.UpsertTxContext(context.Background(), obj, tx)
+}
 
-	query := qb.Insert(insertCols...).OnDuplicate(updateCols)
+// UpsertTxContext a new entry into the database for the Record using a transaction. The BeforeCreator, Validator and
+// AfterCreator interfaces of the Record are called within the transaction.
+func (db *Database) UpsertTxContext(ctx context.Context, obj Record, tx *sqlx.Tx) errors.TracerError {
+	if tracerErr := beforeCreate(obj, tx); nil != tracerErr {
+		return tracerErr
+	}
+	insertCols, updateCols := upsertColumns(obj.Meta())
+	query := qb.Insert(insertCols...).
+		OnDuplicate(updateCols).
+		OnConflict(obj.Meta().PrimaryKey()).
+		Dialect(db.Dialect)
 	stmt, err := query.ParameterizedSQL()
 	if nil != err {
 		return errors.Wrap(err)
 	}
 
-	_, err = tx.NamedExec(stmt, obj)
+	_, err = db.namedExecContext(ctx, tx, obj.Meta().GetName(), Insert, stmt, obj)
 
 	if nil != err {
 		return TranslateError(err, Insert, stmt, db.Logger)
 	}
-	return db.ReadTx(obj, obj.PrimaryKey(), tx)
+	if tracerErr := db.ReadTxContext(ctx, obj, obj.PrimaryKey(), tx); nil != tracerErr {
+		return tracerErr
+	}
+	return afterCreate(obj, tx)
 }
 
 // Read populates a Record from the database
 func (db *Database) Read(obj Record, pk PrimaryKeyValue) errors.TracerError {
-	tx := db.MustBegin()
-	defer tx.Commit() // Since this is a read only no need for a rollback
-	return db.ReadTx(obj, pk, tx)
+	return db.ReadContext(context.Background(), obj, pk)
+}
+
+// ReadContext populates a Record from the database, the read is cancelled with the context
+func (db *Database) ReadContext(ctx context.Context, obj Record, pk PrimaryKeyValue) errors.TracerError {
+	return db.ReadOneWhereContext(ctx, obj, obj.Meta().PrimaryKey().Equal(pk.Value()))
 }
 
 // ReadTx populates a Record from the database using a transaction
 func (db *Database) ReadTx(obj Record, pk PrimaryKeyValue, tx *sqlx.Tx) errors.TracerError {
-	return db.ReadOneWhereTx(obj, tx, obj.Meta().PrimaryKey().Equal(pk.Value()))
+	return db.ReadTxContext(context.Background(), obj, pk, tx)
+}
+
+// ReadTxContext populates a Record from the database using a transaction
+func (db *Database) ReadTxContext(ctx context.Context, obj Record, pk PrimaryKeyValue, tx *sqlx.Tx) errors.TracerError {
+	return db.ReadOneWhereTxContext(ctx, obj, tx, obj.Meta().PrimaryKey().Equal(pk.Value()))
 }
 
 // ReadOneWhere populates a Record from a custom where clause
 func (db *Database) ReadOneWhere(obj Record, condition *qb.ConditionExpression) errors.TracerError {
-	tx, err := db.Beginx()
-	if nil != err {
-		return errors.Wrap(err)
-	}
-	defer tx.Commit() // Since this is a read only no need for a rollback
-	return db.ReadOneWhereTx(obj, tx, condition)
+	return db.ReadOneWhereContext(context.Background(), obj, condition)
+}
+
+// ReadOneWhereContext populates a Record from a custom where clause, the read is cancelled with the context
+func (db *Database) ReadOneWhereContext(ctx context.Context, obj Record,
+	condition *qb.ConditionExpression) errors.TracerError {
+	return db.readOneWhere(ctx, db.reader(), obj, condition)
 }
 
 // ReadOneWhereTx populates a Record from a custom where clause using a transaction
 func (db *Database) ReadOneWhereTx(obj Record, tx *sqlx.Tx, condition *qb.ConditionExpression) errors.TracerError {
-	stmt, args, err := qb.Select(obj.Meta().AllColumns()).From(obj.Meta()).Where(condition).SQL(1, 0)
+	return db.ReadOneWhereTxContext(context.Background(), obj, tx, condition)
+}
+
+// ReadOneWhereTxContext populates a Record from a custom where clause using a transaction
+func (db *Database) ReadOneWhereTxContext(ctx context.Context, obj Record, tx *sqlx.Tx,
+	condition *qb.ConditionExpression) errors.TracerError {
+	return db.readOneWhere(ctx, tx, obj, condition)
+}
+
+func (db *Database) readOneWhere(ctx context.Context, queryer sqlx.QueryerContext, obj Record,
+	condition *qb.ConditionExpression) errors.TracerError {
+	stmt, args, err := qb.Select(obj.Meta().AllColumns()).
+		From(obj.Meta()).
+		Where(db.notDeleted(obj.Meta(), condition)).
+		Dialect(db.Dialect).
+		SQL(1, 0)
 	if nil != err {
 		return errors.Wrap(err)
 	}
 
-	if err = tx.QueryRowx(stmt, args...).StructScan(obj); nil != err {
+	if err = db.getContext(ctx, queryer, obj.Meta().GetName(), obj, stmt, args...); nil != err {
 		return TranslateError(err, Select, stmt, db.Logger)
 	}
 	return nil
@@ -249,14 +318,21 @@ func (db *Database) ReadOneWhereTx(obj Record, tx *sqlx.Tx, condition *qb.Condit
 
 // List populates obj with a list of Records from the database
 func (db *Database) List(def Record, obj interface{}, options *ListOptions) errors.TracerError {
-	stmt, _, err := qb.Select(def.Meta().AllColumns()).
+	return db.ListContext(context.Background(), def, obj, options)
+}
+
+// ListContext populates obj with a list of Records from the database, the query is cancelled with the context
+func (db *Database) ListContext(ctx context.Context, def Record, obj interface{}, options *ListOptions) errors.TracerError {
+	stmt, values, err := qb.Select(def.Meta().AllColumns()).
 		From(def.Meta()).
+		Where(db.notDeleted(def.Meta(), nil)).
 		OrderBy(def.Meta().SortBy()).
+		Dialect(db.Dialect).
 		SQL(options.Limit, options.Offset)
 	if err != nil {
 		return errors.Wrap(err)
 	}
-	if err = db.DB.Select(obj, stmt); nil != err {
+	if err = db.selectContext(ctx, db.reader(), def.Meta().GetName(), obj, stmt, values...); nil != err {
 		return TranslateError(err, Select, stmt, db.Logger)
 	}
 	return nil
@@ -264,17 +340,29 @@ func (db *Database) List(def Record, obj interface{}, options *ListOptions) erro
 
 // ListWhere populates obj with a list of Records from the database
 func (db *Database) ListWhere(def Record, target interface{}, condition *qb.ConditionExpression) errors.TracerError {
-	return db.Select(target, db.buildListWhere(def, condition))
+	return db.ListWhereContext(context.Background(), def, target, condition)
+}
+
+// ListWhereContext populates obj with a list of Records from the database, the query is cancelled with the context
+func (db *Database) ListWhereContext(ctx context.Context, def Record, target interface{},
+	condition *qb.ConditionExpression) errors.TracerError {
+	return db.SelectContext(ctx, target, db.buildListWhere(def, condition))
 }
 
 // ListWhereTx populates obj with a list of Records from the database using the transaction
 func (db *Database) ListWhereTx(tx *sqlx.Tx, def Record, obj interface{}, where *qb.ConditionExpression) errors.TracerError {
+	return db.ListWhereTxContext(context.Background(), tx, def, obj, where)
+}
+
+// ListWhereTxContext populates obj with a list of Records from the database using the transaction
+func (db *Database) ListWhereTxContext(ctx context.Context, tx *sqlx.Tx, def Record, obj interface{},
+	where *qb.ConditionExpression) errors.TracerError {
 	query, values, err := db.buildListWhere(def, where).SQL(qb.NoLimit, 0)
 	if nil != err {
 		return errors.Wrap(err)
 	}
 
-	if err = tx.Select(obj, query, values...); nil != err {
+	if err = db.selectContext(ctx, tx, def.Meta().GetName(), obj, query, values...); nil != err {
 		return TranslateError(err, Select, query, db.Logger)
 	}
 	return nil
@@ -283,27 +371,52 @@ func (db *Database) ListWhereTx(tx *sqlx.Tx, def Record, obj interface{}, where
 func (db *Database) buildListWhere(def Record, condition *qb.ConditionExpression) *qb.SelectQuery {
 	return qb.Select(def.Meta().AllColumns()).
 		From(def.Meta()).
-		Where(condition).
-		OrderBy(def.Meta().SortBy())
+		Where(db.notDeleted(def.Meta(), condition)).
+		OrderBy(def.Meta().SortBy()).
+		Dialect(db.Dialect)
 }
 
 // Select executes a given select query and populates the target
 func (db *Database) Select(target interface{}, query *qb.SelectQuery) errors.TracerError {
-	tx, err := db.Beginx()
-	if nil != err {
-		return errors.Wrap(err)
-	}
-	defer tx.Commit() // Since this is a read only no need for a rollback
-	return db.SelectTx(tx, target, query)
+	return db.SelectContext(context.Background(), target, query)
+}
+
+// SelectContext executes a given select query and populates the target, the query is cancelled with the context
+func (db *Database) SelectContext(ctx context.Context, target interface{}, query *qb.SelectQuery) errors.TracerError {
+	return db.selectQuery(ctx, db.reader(), target, query)
 }
 
 // SelectTx executes a given select query and populates the target
 func (db *Database) SelectTx(tx *sqlx.Tx, target interface{}, query *qb.SelectQuery) errors.TracerError {
-	stmt, values, err := query.SQL(qb.NoLimit, 0)
+	return db.SelectTxContext(context.Background(), tx, target, query)
+}
+
+// SelectTxContext executes a given select query and populates the target
+func (db *Database) SelectTxContext(ctx context.Context, tx *sqlx.Tx, target interface{},
+	query *qb.SelectQuery) errors.TracerError {
+	return db.selectQuery(ctx, tx, target, query)
+}
+
+// selectSQL renders the query for the database dialect, falling back to the query's own dialect, without changing the
+// dialect set on the caller's query
+func (db *Database) selectSQL(query *qb.SelectQuery, limit, offset uint) (string, []interface{}, error) {
+	if nil == db.Dialect {
+		return query.SQL(limit, offset)
+	}
+	return query.DialectSQL(db.Dialect, limit, offset)
+}
+
+func (db *Database) selectQuery(ctx context.Context, queryer sqlx.QueryerContext, target interface{},
+	query *qb.SelectQuery) errors.TracerError {
+	stmt, values, err := db.selectSQL(query, qb.NoLimit, 0)
 	if err != nil {
 		return errors.Wrap(err)
 	}
-	if err = db.DB.Select(target, stmt, values...); nil != err {
+	table := ""
+	if nil != query.Table() {
+		table = query.Table().GetName()
+	}
+	if err = db.selectContext(ctx, queryer, table, target, stmt, values...); nil != err {
 		return TranslateError(err, Select, stmt, db.Logger)
 	}
 	return nil
@@ -311,68 +424,188 @@ func (db *Database) SelectTx(tx *sqlx.Tx, target interface{}, query *qb.SelectQu
 
 // Update replaces an entry in the database for the Record
 func (db *Database) Update(obj Record) errors.TracerError {
-	tx, err := db.Beginx()
-	if nil != err {
-		return errors.Wrap(err)
-	}
-	err = db.UpdateTx(obj, tx)
-	return CommitOrRollback(tx, err)
+	return db.UpdateContext(context.Background(), obj)
+}
+
+// UpdateContext replaces an entry in the database for the Record, the update is cancelled with the context
+func (db *Database) UpdateContext(ctx context.Context, obj Record) errors.TracerError {
+	return db.InTxContext(ctx, func(tx *sqlx.Tx) error {
+		return db.UpdateTxContext(ctx, obj, tx)
+	})
 }
 
 // UpdateTx replaces an entry in the database for the Record using a transaction
 func (db *Database) UpdateTx(obj Record, tx *sqlx.Tx) errors.TracerError {
-	query := qb.Update(obj.Meta())
-	for _, col := range obj.Meta().WriteColumns() {
-		query.SetParam(col)
+	return db.UpdateTxContext(context.Background(), obj, tx)
+}
+
+// UpdateTxContext replaces an entry in the database for the Record using a transaction. When the Record's table is a
+// qb.VersionedTable a StaleRecordError is returned if the row has been updated since the Record was read. When the
+// Record is Tracked only the columns that have changed since it was read are written. The BeforeUpdater, Validator and
+// AfterUpdater interfaces of the Record are called within the transaction.
+func (db *Database) UpdateTxContext(ctx context.Context, obj Record, tx *sqlx.Tx) errors.TracerError {
+	if tracerErr := beforeUpdate(obj, tx); nil != tracerErr {
+		return tracerErr
+	}
+	columns, tracerErr := db.DirtyColumns(obj)
+	if nil != tracerErr {
+		return tracerErr
+	}
+	return db.updateColumns(ctx, obj, tx, columns)
+}
+
+// updateColumns writes the columns of the Record and reads it back, nothing is written when there are no columns
+func (db *Database) updateColumns(ctx context.Context, obj Record, tx *sqlx.Tx,
+	columns []qb.TableField) errors.TracerError {
+	if len(columns) == 0 {
+		return afterUpdate(obj, tx)
+	}
+	meta := obj.Meta()
+	versioned, isVersioned := meta.(qb.VersionedTable)
+	query := qb.Update(meta).Dialect(db.Dialect)
+	for _, col := range columns {
+		if !isVersioned || col != versioned.Version() {
+			query.SetParam(col)
+		}
+	}
+	where := meta.PrimaryKey().Equal(":" + meta.PrimaryKey().GetName())
+	if isVersioned {
+		version := versioned.Version()
+		next, err := db.nextVersion(obj, version)
+		if nil != err {
+			return err
+		}
+		query.Set(version, next)
+		where = where.And(version.Equal(":" + version.GetName()))
 	}
-	query.Where(obj.Meta().PrimaryKey().Equal(":" + obj.Meta().PrimaryKey().GetName()))
+	query.Where(where)
 	stmt, err := query.ParameterizedSQL(qb.NoLimit)
 	if nil != err {
 		return errors.Wrap(err)
 	}
 
-	_, err = tx.NamedExec(stmt, obj)
+	result, err := db.namedExecContext(ctx, tx, meta.GetName(), Update, stmt, obj)
 	if nil != err {
 		return TranslateError(err, Update, stmt, db.Logger)
 	}
+	if isVersioned {
+		if affected, err := result.RowsAffected(); nil == err && 0 == affected {
+			if tracerErr := db.checkVersion(ctx, obj, versioned, tx); nil != tracerErr {
+				return tracerErr
+			}
+		}
+	}
 
-	return db.ReadTx(obj, obj.PrimaryKey(), tx)
+	if tracerErr := db.ReadTxContext(ctx, obj, obj.PrimaryKey(), tx); nil != tracerErr {
+		return tracerErr
+	}
+	return afterUpdate(obj, tx)
 }
 
-// Delete removes a row from the database
-func (db *Database) Delete(obj Record) errors.TracerError {
-	tx, err := db.Beginx()
+// nextVersion returns the value assigned to the version column of the Record on update. Only integer versions are
+// supported, a timestamp has a resolution of a second so two updates within the same second would share a version.
+func (db *Database) nextVersion(obj Record, version qb.TableField) (interface{}, errors.TracerError) {
+	field, ok := db.Mapper.FieldMap(reflect.ValueOf(obj))[version.GetName()]
+	if !ok {
+		return nil, NewValidationError("%s has no field for version column %s", reflect.TypeOf(obj), version.GetName())
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return qb.Raw(version.SQL() + " + 1"), nil
+	}
+	return nil, NewValidationError("version column %s of %s must be an integer", version.GetName(), reflect.TypeOf(obj))
+}
+
+// checkVersion returns a StaleRecordError if the row for the Record no longer has the version of the Record. Rows that
+// match but were not changed by an update also report zero rows affected.
+func (db *Database) checkVersion(ctx context.Context, obj Record, meta qb.VersionedTable,
+	tx *sqlx.Tx) errors.TracerError {
+	version := meta.Version()
+	field := db.Mapper.FieldMap(reflect.ValueOf(obj))[version.GetName()]
+	stmt, values, err := qb.Select(qb.Count(meta.PrimaryKey(), "count")).
+		From(meta).
+		Where(meta.PrimaryKey().Equal(obj.PrimaryKey().Value()).And(version.Equal(field.Interface()))).
+		Dialect(db.Dialect).
+		SQL(qb.NoLimit, 0)
 	if nil != err {
 		return errors.Wrap(err)
 	}
-	err = db.DeleteTx(obj, tx)
-	return CommitOrRollback(tx, err)
+	var count int
+	if err = db.getContext(ctx, tx, meta.GetName(), &count, stmt, values...); nil != err {
+		return TranslateError(err, Select, stmt, db.Logger)
+	}
+	if 0 == count {
+		return NewStaleRecordError(meta.GetName())
+	}
+	return nil
+}
+
+// Delete removes a row from the database
+func (db *Database) Delete(obj Record) errors.TracerError {
+	return db.DeleteContext(context.Background(), obj)
+}
+
+// DeleteContext removes a row from the database, the delete is cancelled with the context
+func (db *Database) DeleteContext(ctx context.Context, obj Record) errors.TracerError {
+	return db.InTxContext(ctx, func(tx *sqlx.Tx) error {
+		return db.DeleteTxContext(ctx, obj, tx)
+	})
 }
 
 // DeleteTx removes a row from the database using a transaction
 func (db *Database) DeleteTx(obj Record, tx *sqlx.Tx) errors.TracerError {
+	return db.DeleteTxContext(context.Background(), obj, tx)
+}
+
+// DeleteTxContext removes a row from the database using a transaction, the BeforeDeleter interface of the Record is
+// called within the transaction
+func (db *Database) DeleteTxContext(ctx context.Context, obj Record, tx *sqlx.Tx) errors.TracerError {
+	if tracerErr := beforeDelete(obj, tx); nil != tracerErr {
+		return tracerErr
+	}
 	where := obj.Meta().PrimaryKey().Equal(obj.PrimaryKey().Value())
-	return db.DeleteWhereTx(obj, tx, where)
+	return db.DeleteWhereTxContext(ctx, obj, tx, where)
 }
 
 // DeleteWhere removes a row(s) from the database based on a supplied where clause
 func (db *Database) DeleteWhere(obj Record, where *qb.ConditionExpression) errors.TracerError {
-	tx, err := db.Beginx()
-	if nil != err {
-		return errors.Wrap(err)
-	}
-	err = db.DeleteWhereTx(obj, tx, where)
-	return CommitOrRollback(tx, err)
+	return db.DeleteWhereContext(context.Background(), obj, where)
+}
+
+// DeleteWhereContext removes a row(s) from the database based on a supplied where clause, the delete is cancelled
+// with the context
+func (db *Database) DeleteWhereContext(ctx context.Context, obj Record, where *qb.ConditionExpression) errors.TracerError {
+	return db.InTxContext(ctx, func(tx *sqlx.Tx) error {
+		return db.DeleteWhereTxContext(ctx, obj, tx, where)
+	})
 }
 
 // DeleteWhereTx removes row(s) from the database based on a supplied where clause in a transaction
 func (db *Database) DeleteWhereTx(obj Record, tx *sqlx.Tx, condition *qb.ConditionExpression) errors.TracerError {
-	stmt, values, err := qb.Delete(obj.Meta()).Where(condition).SQL()
+	return db.DeleteWhereTxContext(context.Background(), obj, tx, condition)
+}
+
+// DeleteWhereTxContext removes row(s) from the database based on a supplied where clause in a transaction. Rows of a
+// qb.SoftDeleteTable are marked as deleted instead of being removed.
+func (db *Database) DeleteWhereTxContext(ctx context.Context, obj Record, tx *sqlx.Tx,
+	condition *qb.ConditionExpression) errors.TracerError {
+	if softDelete, ok := obj.Meta().(qb.SoftDeleteTable); ok {
+		return db.softDeleteWhereTx(ctx, softDelete, tx, condition)
+	}
+	return db.HardDeleteWhereTxContext(ctx, obj, tx, condition)
+}
+
+// HardDeleteWhereTxContext removes row(s) from the database based on a supplied where clause in a transaction, even
+// if the Record's table is a qb.SoftDeleteTable
+func (db *Database) HardDeleteWhereTxContext(ctx context.Context, obj Record, tx *sqlx.Tx,
+	condition *qb.ConditionExpression) errors.TracerError {
+	stmt, values, err := qb.Delete(obj.Meta()).Where(condition).Dialect(db.Dialect).SQL()
 	if nil != err {
 		return errors.Wrap(err)
 	}
 
-	_, err = tx.Exec(stmt, values...)
+	_, err = db.execContext(ctx, tx, obj.Meta().GetName(), Delete, stmt, values...)
 
 	if nil != err {
 		return TranslateError(err, Delete, stmt, db.Logger)
@@ -388,3 +621,11 @@ func CommitOrRollback(tx *sqlx.Tx, err error) errors.TracerError {
 	}
 	return errors.Wrap(tx.Commit())
 }
+
+// CommitOrRollbackContext will rollback on an errors.TracerError or if the context is done, otherwise commit
+func CommitOrRollbackContext(ctx context.Context, tx *sqlx.Tx, err error) errors.TracerError {
+	if nil == err && nil != ctx.Err() {
+		err = TranslateError(ctx.Err(), Transaction, "COMMIT", log.Global())
+	}
+	return CommitOrRollback(tx, err)
+}
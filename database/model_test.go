@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"testing"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/Kasita-Inc/gadget/database/qb"
 	"github.com/Kasita-Inc/gadget/generator"
 	"github.com/Kasita-Inc/gadget/log"
 )
@@ -100,6 +102,37 @@ func TestRead(t *testing.T) {
 	assert.Equal(expected, actual)
 }
 
+func TestReadContext(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	expected := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.CreateContext(ctx, expected))
+
+	actual := &TestRecord{}
+	assert.NoError(spec.DB.ReadContext(ctx, actual, expected.PrimaryKey()))
+	assert.Equal(expected, actual)
+}
+
+func TestContextDeadlineExceeded(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	record := &TestRecord{Name: generator.Name()}
+	assert.IsType(&DeadlineExceededError{}, spec.DB.CreateContext(ctx, record))
+	assert.IsType(&DeadlineExceededError{}, spec.DB.ReadContext(ctx, record, record.PrimaryKey()))
+	assert.IsType(&DeadlineExceededError{}, spec.DB.ListWhereContext(ctx, record, &[]TestRecord{}, TestMeta.Name.Equal(record.Name)))
+
+	tx := spec.DB.MustBegin()
+	assert.IsType(&DeadlineExceededError{}, spec.DB.ReadTxContext(ctx, record, record.PrimaryKey(), tx))
+	assert.IsType(&DeadlineExceededError{}, CommitOrRollbackContext(ctx, tx, nil))
+	assert.IsType(&NotFoundError{}, spec.DB.Read(record, record.PrimaryKey()))
+}
+
 func TestReadOneWhere(t *testing.T) {
 	assert := assert.New(t)
 	spec := newSpecification()
@@ -317,6 +350,8 @@ func TestUpdate(t *testing.T) {
 }
 
 func TestUpsertTx(t *testing.T) {
+	// relies on ON UPDATE CURRENT_TIMESTAMP ignoring an updated_on that is assigned explicitly
+	skipUnlessMySQL(t)
 	assert := assert.New(t)
 	spec := newSpecification()
 
@@ -406,3 +441,47 @@ func TestDuplicateID(t *testing.T) {
 	assert.Equal("nodupe", record3.ID[:6])
 	assert.NotEqual(record3.ID, record.ID)
 }
+
+func TestUpdateVersioned(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	record := &TestVersionedRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.Create(record))
+	assert.Equal(1, record.Version)
+
+	stale := &TestVersionedRecord{}
+	assert.NoError(spec.DB.Read(stale, record.PrimaryKey()))
+
+	record.Name = generator.Name()
+	assert.NoError(spec.DB.Update(record))
+	assert.Equal(2, record.Version)
+
+	// updating without changes still increments the version
+	assert.NoError(spec.DB.Update(record))
+	assert.Equal(3, record.Version)
+
+	stale.Name = generator.Name()
+	err := spec.DB.Update(stale)
+	assert.IsType(&StaleRecordError{}, err)
+	assert.EqualError(err, "test_versioned record has been modified since it was read")
+
+	actual := &TestVersionedRecord{}
+	assert.NoError(spec.DB.Read(actual, record.PrimaryKey()))
+	assert.Equal(record, actual)
+}
+
+func TestNextVersion(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	next, err := spec.DB.nextVersion(&TestVersionedRecord{}, TestVersionedMeta.Version())
+	assert.NoError(err)
+	assert.Equal(qb.Raw("`test_versioned`.`version` + 1"), next)
+
+	// a timestamp cannot distinguish updates within the same second
+	_, err = spec.DB.nextVersion(&TestRecord{}, TestMeta.CreatedOn)
+	assert.EqualError(err, "version column created_on of *database.TestRecord must be an integer")
+	_, err = spec.DB.nextVersion(&TestRecord{}, qb.TableField{Name: "missing", Table: "test_record"})
+	assert.IsType(&ValidationError{}, err)
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Kasita-Inc/gadget/database/qb"
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+// Preload populates the named relations of target, a pointer to a Record or to a slice of Records, using one query per
+// relation. The relations are declared by the qb.RelatedTable of the Record and are assigned to the struct field with
+// the same name as the relation, a slice for qb.HasMany and a struct or pointer for qb.BelongsTo.
+//
+//	db.ListWhere(&Order{}, &orders, condition)
+//	db.Preload(&orders, "Items", "Customer")
+func (db *Database) Preload(target interface{}, relations ...string) errors.TracerError {
+	return db.PreloadContext(context.Background(), target, relations...)
+}
+
+// PreloadContext populates the named relations of target, the queries are cancelled with the context
+func (db *Database) PreloadContext(ctx context.Context, target interface{}, relations ...string) errors.TracerError {
+	return db.preload(ctx, db.reader(), target, relations)
+}
+
+// PreloadTx populates the named relations of target using the transaction
+func (db *Database) PreloadTx(tx *sqlx.Tx, target interface{}, relations ...string) errors.TracerError {
+	return db.preload(context.Background(), tx, target, relations)
+}
+
+func (db *Database) preload(ctx context.Context, queryer sqlx.QueryerContext, target interface{},
+	relations []string) errors.TracerError {
+	parents, meta, err := preloadParents(target)
+	if nil != err || len(parents) == 0 {
+		return err
+	}
+	related, ok := meta.(qb.RelatedTable)
+	if !ok {
+		return NewValidationError("%s does not declare any relations", meta.GetName())
+	}
+	for _, name := range relations {
+		relation, ok := findRelation(related, name)
+		if !ok {
+			return NewValidationError("%s has no relation %s", meta.GetName(), name)
+		}
+		if err := db.preloadRelation(ctx, queryer, parents, relation); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// preloadRelation reads the rows related to all of the parents with a single query and assigns them to the parents
+func (db *Database) preloadRelation(ctx context.Context, queryer sqlx.QueryerContext, parents []reflect.Value,
+	relation qb.Relation) errors.TracerError {
+	field, ok := parents[0].Type().FieldByName(relation.Name)
+	if !ok || "" != field.PkgPath {
+		return NewValidationError("%s has no exported field for relation %s", parents[0].Type(), relation.Name)
+	}
+	rowType := field.Type
+	if qb.HasMany == relation.Type {
+		if reflect.Slice != field.Type.Kind() {
+			return NewValidationError("%s.%s must be a slice", parents[0].Type(), relation.Name)
+		}
+		rowType = field.Type.Elem()
+	}
+
+	parentKeys := make([]interface{}, len(parents))
+	keys := []interface{}{}
+	seen := map[interface{}]bool{}
+	for i, parent := range parents {
+		key, err := db.relationKey(parent, relation.Local)
+		if nil != err {
+			return err
+		}
+		parentKeys[i] = key
+		if nil != key && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	grouped := map[interface{}][]reflect.Value{}
+	// the keys are read in chunks so that no statement exceeds the placeholders allowed by the dialect, all of the
+	// rows of a key are in the same chunk so each group stays in sort order
+	parameters := db.maxParameters()
+	for start := 0; start < len(keys); start += parameters {
+		end := start + parameters
+		if end > len(keys) {
+			end = len(keys)
+		}
+		stmt, values, err := qb.Select(relation.Table.AllColumns()).
+			From(relation.Table).
+			Where(db.notDeleted(relation.Table, relation.Foreign.In(keys[start:end]...))).
+			OrderBy(relation.Table.SortBy()).
+			Dialect(db.Dialect).
+			SQL(qb.NoLimit, 0)
+		if nil != err {
+			return errors.Wrap(err)
+		}
+		rows := reflect.New(reflect.SliceOf(rowType))
+		if err = db.selectContext(ctx, queryer, relation.Table.GetName(), rows.Interface(), stmt, values...); nil != err {
+			return TranslateError(err, Select, stmt, db.Logger)
+		}
+		for i := 0; i < rows.Elem().Len(); i++ {
+			row := rows.Elem().Index(i)
+			key, err := db.relationKey(reflect.Indirect(row), relation.Foreign)
+			if nil != err {
+				return err
+			}
+			grouped[key] = append(grouped[key], row)
+		}
+	}
+
+	for i, parent := range parents {
+		related := grouped[parentKeys[i]]
+		target := parent.FieldByName(relation.Name)
+		if qb.HasMany == relation.Type {
+			slice := reflect.MakeSlice(field.Type, 0, len(related))
+			target.Set(reflect.Append(slice, related...))
+		} else if len(related) > 0 {
+			target.Set(related[0])
+		} else {
+			target.Set(reflect.Zero(field.Type))
+		}
+	}
+	return nil
+}
+
+// relationKey returns the value of the column of the struct in a form that can be compared, nil for NULL
+func (db *Database) relationKey(value reflect.Value, column qb.TableField) (interface{}, errors.TracerError) {
+	field, ok := db.Mapper.FieldMap(value)[column.GetName()]
+	if !ok {
+		return nil, NewValidationError("%s has no field for column %s", value.Type(), column.GetName())
+	}
+	key, err := driver.DefaultParameterConverter.ConvertValue(field.Interface())
+	if nil != err {
+		return nil, errors.Wrap(err)
+	}
+	if bytes, ok := key.([]byte); ok {
+		return string(bytes), nil
+	}
+	return key, nil
+}
+
+// preloadParents returns the addressable structs of the target and their table
+func preloadParents(target interface{}) ([]reflect.Value, qb.Table, errors.TracerError) {
+	value := reflect.ValueOf(target)
+	if reflect.Ptr != value.Kind() || value.IsNil() {
+		return nil, nil, NewNotAPointerError()
+	}
+	value = value.Elem()
+	parents := []reflect.Value{}
+	if reflect.Slice == value.Kind() {
+		for i := 0; i < value.Len(); i++ {
+			if parent := reflect.Indirect(value.Index(i)); parent.IsValid() {
+				parents = append(parents, parent)
+			}
+		}
+	} else {
+		parents = append(parents, value)
+	}
+	if len(parents) == 0 {
+		return parents, nil, nil
+	}
+	record, ok := parents[0].Addr().Interface().(Record)
+	if !ok {
+		return nil, nil, NewValidationError("%s is not a Record", parents[0].Type())
+	}
+	return parents, record.Meta(), nil
+}
+
+func findRelation(table qb.RelatedTable, name string) (qb.Relation, bool) {
+	for _, relation := range table.Relations() {
+		if relation.Name == name {
+			return relation, true
+		}
+	}
+	return qb.Relation{}, false
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/generator"
+)
+
+func TestPreload(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	name := generator.Name()
+	parents := []*TestRecord{{Name: name + "a"}, {Name: name + "b"}, {Name: name + "c"}}
+	for _, parent := range parents {
+		assert.NoError(spec.DB.Create(parent))
+	}
+	items := []*TestItemRecord{
+		{RecordID: parents[0].ID, Name: "1"},
+		{RecordID: parents[0].ID, Name: "2"},
+		{RecordID: parents[1].ID, Name: "3"},
+	}
+	for _, item := range items {
+		assert.NoError(spec.DB.Create(item))
+	}
+
+	actual := []TestRecord{}
+	assert.NoError(spec.DB.ListWhere(&TestRecord{}, &actual, TestMeta.Name.StartsWith(name)))
+	assert.NoError(spec.DB.Preload(&actual, "Items"))
+	if assert.Len(actual, 3) {
+		if assert.Len(actual[0].Items, 2) {
+			assert.Equal("1", actual[0].Items[0].Name)
+			assert.Equal("2", actual[0].Items[1].Name)
+		}
+		assert.Len(actual[1].Items, 1)
+		assert.NotNil(actual[2].Items)
+		assert.Empty(actual[2].Items)
+	}
+
+	item := &TestItemRecord{}
+	assert.NoError(spec.DB.Read(item, items[2].PrimaryKey()))
+	assert.NoError(spec.DB.Preload(item, "Record"))
+	if assert.NotNil(item.Record) {
+		assert.Equal(parents[1].Name, item.Record.Name)
+	}
+
+	assert.IsType(&ValidationError{}, spec.DB.Preload(item, "Missing"))
+	assert.IsType(&NotAPointerError{}, spec.DB.Preload(*item, "Record"))
+	assert.NoError(spec.DB.Preload(&[]TestRecord{}, "Items"))
+}
+
+func TestPreloadParameterLimit(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	// enough parents that SQLite needs more than one statement to read their items
+	name := generator.Name()
+	records := make([]Record, sqliteMaxParameters+1)
+	for i := range records {
+		records[i] = &TestRecord{Name: name + generator.String(10)}
+	}
+	assert.NoError(spec.DB.CreateMany(records, nil))
+	last := records[len(records)-1].(*TestRecord)
+	assert.NoError(spec.DB.Create(&TestItemRecord{RecordID: last.ID, Name: "1"}))
+
+	actual := []*TestRecord{}
+	for _, record := range records {
+		actual = append(actual, record.(*TestRecord))
+	}
+	assert.NoError(spec.DB.Preload(&actual, "Items"))
+	assert.Empty(actual[0].Items)
+	if assert.Len(actual[len(actual)-1].Items, 1) {
+		assert.Equal("1", actual[len(actual)-1].Items[0].Name)
+	}
+}
+
+func TestPreloadParents(t *testing.T) {
+	assert := assert.New(t)
+	parents, meta, err := preloadParents(&[]*TestRecord{{Name: "a"}, nil, {Name: "b"}})
+	assert.NoError(err)
+	assert.Len(parents, 2)
+	assert.Equal(TestMeta, meta)
+
+	parents, meta, err = preloadParents(&TestItemRecord{})
+	assert.NoError(err)
+	assert.Len(parents, 1)
+	assert.Equal(TestItemMeta, meta)
+
+	parents, _, err = preloadParents(&[]TestRecord{})
+	assert.NoError(err)
+	assert.Empty(parents)
+
+	_, _, err = preloadParents(&[]string{"a"})
+	assert.IsType(&ValidationError{}, err)
+	_, _, err = preloadParents(TestRecord{})
+	assert.IsType(&NotAPointerError{}, err)
+}
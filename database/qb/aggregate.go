@@ -0,0 +1,100 @@
+package qb
+
+import (
+	"fmt"
+)
+
+// Aggregate function applied to a column for use as a SelectExpression or in a Having condition.
+type Aggregate struct {
+	function string
+	field    TableField
+	distinct bool
+	alias    string
+}
+
+// Count the non null values of the passed column, or all rows for AllColumns.
+func Count(field TableField, alias string) *Aggregate {
+	return &Aggregate{function: "COUNT", field: field, alias: alias}
+}
+
+// CountDistinct counts the distinct non null values of the passed column.
+func CountDistinct(field TableField, alias string) *Aggregate {
+	return &Aggregate{function: "COUNT", field: field, distinct: true, alias: alias}
+}
+
+// Sum the values of the passed column.
+func Sum(field TableField, alias string) *Aggregate {
+	return &Aggregate{function: "SUM", field: field, alias: alias}
+}
+
+// Avg returns the average of the values of the passed column.
+func Avg(field TableField, alias string) *Aggregate {
+	return &Aggregate{function: "AVG", field: field, alias: alias}
+}
+
+// Min returns the minimum value of the passed column.
+func Min(field TableField, alias string) *Aggregate {
+	return &Aggregate{function: "MIN", field: field, alias: alias}
+}
+
+// Max returns the maximum value of the passed column.
+func Max(field TableField, alias string) *Aggregate {
+	return &Aggregate{function: "MAX", field: field, alias: alias}
+}
+
+// GetName that can be used to reference this expression
+func (a *Aggregate) GetName() string {
+	return a.alias
+}
+
+// GetTables that are used in this expression
+func (a *Aggregate) GetTables() []string {
+	return a.field.GetTables()
+}
+
+// SQL that represents this aggregate as a SelectExpression
+func (a *Aggregate) SQL() string {
+	sql, _ := a.operand(MySQL)
+	return fmt.Sprintf("%s AS `%s`", sql, a.alias)
+}
+
+func (a *Aggregate) operand(dialect Dialect) (string, []interface{}) {
+	column := a.field.SQL()
+	if "*" == a.field.Name {
+		column = "*"
+	}
+	if a.distinct {
+		column = "DISTINCT " + column
+	}
+	return fmt.Sprintf("%s(%s)", a.function, column), []interface{}{}
+}
+
+// Equal returns a condition expression for this aggregate Equal to the passed obj.
+func (a *Aggregate) Equal(obj interface{}) *ConditionExpression {
+	return compare(a, Equal, obj)
+}
+
+// NotEqual returns a condition expression for this aggregate NotEqual to the passed obj.
+func (a *Aggregate) NotEqual(obj interface{}) *ConditionExpression {
+	return compare(a, NotEqual, obj)
+}
+
+// LessThan returns a condition expression for this aggregate LessThan the passed obj.
+func (a *Aggregate) LessThan(obj interface{}) *ConditionExpression {
+	return compare(a, LessThan, obj)
+}
+
+// LessThanEqual returns a condition expression for this aggregate LessThanEqual to the passed obj.
+func (a *Aggregate) LessThanEqual(obj interface{}) *ConditionExpression {
+	return compare(a, LessThanEqual, obj)
+}
+
+// GreaterThan returns a condition expression for this aggregate GreaterThan the passed obj.
+func (a *Aggregate) GreaterThan(obj interface{}) *ConditionExpression {
+	return compare(a, GreaterThan, obj)
+}
+
+// GreaterThanEqual returns a condition expression for this aggregate GreaterThanEqual to the passed obj.
+func (a *Aggregate) GreaterThanEqual(obj interface{}) *ConditionExpression {
+	return compare(a, GreaterThanEqual, obj)
+}
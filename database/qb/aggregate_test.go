@@ -0,0 +1,67 @@
+package qb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregates(t *testing.T) {
+	assert := assert.New(t)
+	data := []struct {
+		aggregate SelectExpression
+		expected  string
+	}{
+		{Count(Person.AllColumns(), "total"), "COUNT(*) AS `total`"},
+		{Count(Person.ID, "total"), "COUNT(`person`.`id`) AS `total`"},
+		{CountDistinct(Person.Name, "names"), "COUNT(DISTINCT `person`.`name`) AS `names`"},
+		{Sum(Person.ID, "sum"), "SUM(`person`.`id`) AS `sum`"},
+		{Avg(Person.ID, "avg"), "AVG(`person`.`id`) AS `avg`"},
+		{Min(Person.ID, "min"), "MIN(`person`.`id`) AS `min`"},
+		{Max(Person.ID, "max"), "MAX(`person`.`id`) AS `max`"},
+	}
+	for _, test := range data {
+		assert.Equal(test.expected, test.aggregate.SQL())
+		assert.Equal([]string{"person"}, test.aggregate.GetTables())
+	}
+}
+
+func TestAggregateGroupByHaving(t *testing.T) {
+	assert := assert.New(t)
+	count := Count(Person.ID, "people")
+	query := Select(Person.AddressID, count, Max(Person.Name, "last_name")).
+		From(Person).
+		Where(Person.Name.NotEqual("Jim")).
+		GroupBy(Person.AddressID).
+		Having(count.GreaterThan(2).And(Max(Person.Name, "").NotEqual("Zed")))
+	actual, values, err := query.SQL(0, 0)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"Jim", 2, "Zed"}, values)
+	assert.Equal("SELECT `person`.`address_id`, COUNT(`person`.`id`) AS `people`, MAX(`person`.`name`) AS `last_name` "+
+		"FROM `person` AS `person` "+
+		"WHERE `person`.`name` != ? "+
+		"GROUP BY `person`.`address_id` "+
+		"HAVING (COUNT(`person`.`id`) > ? AND MAX(`person`.`name`) != ?)", actual)
+}
+
+func TestAggregateHavingMissingTable(t *testing.T) {
+	assert := assert.New(t)
+	query := Select(Person.AddressID).From(Person).GroupBy(Person.AddressID).
+		Having(Count(Address.ID, "addresses").GreaterThan(1))
+	_, _, err := query.SQL(0, 0)
+	assert.EqualError(err, NewMissingTablesError([]string{Address.GetName()}).Error())
+}
+
+func TestAggregatePostgres(t *testing.T) {
+	assert := assert.New(t)
+	query := Select(Person.AddressID, Sum(Person.ID, "total")).
+		From(Person).
+		GroupBy(Person.AddressID).
+		Having(Sum(Person.ID, "total").LessThanEqual(10)).
+		Dialect(Postgres)
+	actual, values, err := query.SQL(0, 0)
+	assert.NoError(err)
+	assert.Equal([]interface{}{10}, values)
+	assert.Equal(`SELECT "person"."address_id", SUM("person"."id") AS "total" FROM "person" AS "person" `+
+		`GROUP BY "person"."address_id" HAVING SUM("person"."id") <= $1`, actual)
+}
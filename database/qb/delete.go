@@ -20,6 +20,8 @@ type DeleteQuery struct {
 	from   Table
 	joins  []*Join
 	where  *whereCondition
+	// dialect used to render the query, multiple tables and joins are MySQL extensions
+	dialect Dialect
 	// NICE TO HAVE: Add orderby and limit logic, order by and limit only apply to single table case
 	err error
 }
@@ -29,6 +31,12 @@ func (q *DeleteQuery) GetAlias(tableName string) string {
 	return tableName
 }
 
+// Dialect sets the database dialect used to render this query, defaults to MySQL.
+func (q *DeleteQuery) Dialect(dialect Dialect) *DeleteQuery {
+	q.dialect = dialect
+	return q
+}
+
 // From sets the primary table the query will find rows in.
 func (q *DeleteQuery) From(table Table) *DeleteQuery {
 	q.from = table
@@ -82,6 +90,7 @@ func (q *DeleteQuery) SQL() (string, []interface{}, error) {
 	if !q.Validate() {
 		return "", nil, q.err
 	}
+	dialect := dialectOrDefault(q.dialect)
 	lines := []string{"DELETE"}
 	values := []interface{}{}
 	rowsInLines := make([]string, len(q.tables))
@@ -100,16 +109,16 @@ func (q *DeleteQuery) SQL() (string, []interface{}, error) {
 
 	// JOIN
 	for _, join := range q.joins {
-		joinSQL, joinValues := join.SQL()
+		joinSQL, joinValues := join.sql(dialect)
 		lines = append(lines, joinSQL)
 		values = append(values, joinValues...)
 	}
 
 	// WHERE
-	if where, whereValues, ok := q.where.sql(); ok {
+	if where, whereValues, ok := q.where.sql(dialect); ok {
 		lines = append(lines, "WHERE", where)
 		values = append(values, whereValues...)
 	}
 
-	return strings.Join(lines, " "), values, q.err
+	return bind(dialect, strings.Join(lines, " ")), values, q.err
 }
@@ -0,0 +1,233 @@
+package qb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+// Dialect defines the database specific syntax used when rendering a query.
+//
+// Queries are built using MySQL syntax (back tick quoted identifiers and '?' placeholders), the dialect is then used to
+// translate the statement for the target database.
+type Dialect interface {
+	// Name of the database/sql driver this dialect renders for
+	Name() string
+	// Quote an identifier such as a table, column or alias name
+	Quote(identifier string) string
+	// Placeholder for the bound value at the passed position, positions start at 1
+	Placeholder(position int) string
+	// Compare the left and right expressions using the passed comparison
+	Compare(left string, comparison Comparison, right string) string
+	// Conjoin the left and right conditions using the passed conjunction (AND, OR, XOR)
+	Conjoin(left string, conjunction string, right string) string
+	// Now is the SQL function for the current timestamp
+	Now() string
+	// QualifyAssignments indicates if the target columns of an insert or update may include the table name
+	QualifyAssignments() bool
+	// Upsert returns the clause appended to an insert that updates the assignments when the keys conflict
+	Upsert(keys []string, assignments []string) (string, error)
+	// Inserted references the value that would have been inserted into the column when used in an Upsert
+	Inserted(column string) string
+}
+
+const (
+	mysqlDriver    = "mysql"
+	postgresDriver = "postgres"
+	sqliteDriver   = "sqlite3"
+)
+
+var (
+	// MySQL Dialect, the default used by all queries
+	MySQL Dialect = mysqlDialect{}
+	// Postgres Dialect for PostgreSQL databases
+	Postgres Dialect = postgresDialect{}
+	// SQLite Dialect for SQLite 3 databases
+	SQLite Dialect = sqliteDialect{}
+)
+
+// DialectFor the passed database/sql driver name, defaults to MySQL for unknown drivers.
+func DialectFor(driver string) Dialect {
+	switch strings.ToLower(driver) {
+	case postgresDriver, "postgresql", "pgx":
+		return Postgres
+	case sqliteDriver, "sqlite":
+		return SQLite
+	default:
+		return MySQL
+	}
+}
+
+func dialectOrDefault(dialect Dialect) Dialect {
+	if nil == dialect {
+		return MySQL
+	}
+	return dialect
+}
+
+// bind translates a statement built with MySQL quoting and placeholders into the passed dialect. String literals
+// are left untouched.
+func bind(dialect Dialect, sql string) string {
+	if dialect == MySQL {
+		return sql
+	}
+	var builder strings.Builder
+	position := 0
+	for i := 0; i < len(sql); i++ {
+		switch sql[i] {
+		case '\'':
+			end := literalEnd(sql, i)
+			builder.WriteString(sql[i:end])
+			i = end - 1
+		case '`':
+			end := strings.IndexByte(sql[i+1:], '`')
+			if end < 0 {
+				builder.WriteString(sql[i:])
+				return builder.String()
+			}
+			builder.WriteString(dialect.Quote(sql[i+1 : i+1+end]))
+			i += end + 1
+		case '?':
+			position++
+			builder.WriteString(dialect.Placeholder(position))
+		default:
+			builder.WriteByte(sql[i])
+		}
+	}
+	return builder.String()
+}
+
+// literalEnd returns the index after the string literal that starts at the passed index, quotes inside the literal
+// are escaped by doubling them
+func literalEnd(sql string, start int) int {
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\'':
+			if i+1 < len(sql) && '\'' == sql[i+1] {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return mysqlDriver
+}
+
+func (mysqlDialect) Quote(identifier string) string {
+	return "`" + strings.Replace(identifier, "`", "``", -1) + "`"
+}
+
+func (mysqlDialect) Placeholder(position int) string {
+	return "?"
+}
+
+func (mysqlDialect) Compare(left string, comparison Comparison, right string) string {
+	return fmt.Sprintf("%s %s %s", left, comparison, right)
+}
+
+func (mysqlDialect) Conjoin(left string, conjunction string, right string) string {
+	return fmt.Sprintf("(%s %s %s)", left, conjunction, right)
+}
+
+func (mysqlDialect) Now() string {
+	return SQLNow
+}
+
+func (mysqlDialect) QualifyAssignments() bool {
+	return true
+}
+
+func (mysqlDialect) Upsert(keys []string, assignments []string) (string, error) {
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", "), nil
+}
+
+func (mysqlDialect) Inserted(column string) string {
+	return fmt.Sprintf("VALUES(`%s`)", column)
+}
+
+// standardDialect implements the parts of the SQL standard shared by Postgres and SQLite
+type standardDialect struct{}
+
+func (standardDialect) Quote(identifier string) string {
+	return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
+}
+
+func (standardDialect) Conjoin(left string, conjunction string, right string) string {
+	if XOr == conjunction {
+		return fmt.Sprintf("((%s) != (%s))", left, right)
+	}
+	return fmt.Sprintf("(%s %s %s)", left, conjunction, right)
+}
+
+func (standardDialect) QualifyAssignments() bool {
+	return false
+}
+
+func (standardDialect) Upsert(keys []string, assignments []string) (string, error) {
+	if len(keys) == 0 {
+		return "", errors.New("on duplicate requires conflict keys for this dialect")
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keys, ", "),
+		strings.Join(assignments, ", ")), nil
+}
+
+func (standardDialect) Inserted(column string) string {
+	return fmt.Sprintf("EXCLUDED.`%s`", column)
+}
+
+type postgresDialect struct {
+	standardDialect
+}
+
+func (postgresDialect) Name() string {
+	return postgresDriver
+}
+
+func (postgresDialect) Placeholder(position int) string {
+	return fmt.Sprintf("$%d", position)
+}
+
+func (postgresDialect) Compare(left string, comparison Comparison, right string) string {
+	if NullSafeEqual == comparison {
+		return fmt.Sprintf("%s IS NOT DISTINCT FROM %s", left, right)
+	}
+	return fmt.Sprintf("%s %s %s", left, comparison, right)
+}
+
+func (postgresDialect) Now() string {
+	return SQLNow
+}
+
+type sqliteDialect struct {
+	standardDialect
+}
+
+func (sqliteDialect) Name() string {
+	return sqliteDriver
+}
+
+func (sqliteDialect) Placeholder(position int) string {
+	return "?"
+}
+
+func (sqliteDialect) Compare(left string, comparison Comparison, right string) string {
+	switch comparison {
+	case NullSafeEqual:
+		return fmt.Sprintf("%s IS %s", left, right)
+	case Like, NotLike:
+		// SQLite has no default escape character for LIKE
+		return fmt.Sprintf("%s %s %s ESCAPE '\\'", left, comparison, right)
+	}
+	return fmt.Sprintf("%s %s %s", left, comparison, right)
+}
+
+func (sqliteDialect) Now() string {
+	return "CURRENT_TIMESTAMP"
+}
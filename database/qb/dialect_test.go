@@ -0,0 +1,112 @@
+package qb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialectFor(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(MySQL, DialectFor("mysql"))
+	assert.Equal(MySQL, DialectFor(""))
+	assert.Equal(Postgres, DialectFor("postgres"))
+	assert.Equal(Postgres, DialectFor("pgx"))
+	assert.Equal(SQLite, DialectFor("sqlite3"))
+}
+
+func TestDialectBind(t *testing.T) {
+	assert := assert.New(t)
+	sql := "SELECT `a`.`b`, 'it''s `x` ?' FROM `a` WHERE `a`.`b` = ? AND `a`.`c` = ?"
+	assert.Equal(sql, bind(MySQL, sql))
+	assert.Equal(`SELECT "a"."b", 'it''s `+"`x`"+` ?' FROM "a" WHERE "a"."b" = $1 AND "a"."c" = $2`, bind(Postgres, sql))
+	assert.Equal(`SELECT "a"."b", 'it''s `+"`x`"+` ?' FROM "a" WHERE "a"."b" = ? AND "a"."c" = ?`, bind(SQLite, sql))
+}
+
+func TestSelectPostgres(t *testing.T) {
+	assert := assert.New(t)
+	query := Select(Person.ID, Person.Name, Address.Line).From(Person).Dialect(Postgres)
+	query.InnerJoin(Address).On(Person.AddressID, Equal, Address.ID)
+	query.Where(Person.Name.NullSafeEqual("Jim").XOr(Address.ID.In(1, 2)))
+	actual, values, err := query.SQL(10, 5)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"Jim", 1, 2}, values)
+	assert.Equal(`SELECT "person"."id", "person"."name", "address"."line" `+
+		`FROM "person" AS "person" `+
+		`INNER JOIN "address" AS "address" ON "person"."address_id" = "address"."id" `+
+		`WHERE (("person"."name" IS NOT DISTINCT FROM $1) != ("address"."id" IN ($2, $3))) `+
+		`LIMIT 10 OFFSET 5`, actual)
+}
+
+func TestSelectSQLite(t *testing.T) {
+	assert := assert.New(t)
+	query := Select(Person.ID).From(Person).Dialect(SQLite)
+	query.Where(Person.Name.NullSafeEqual("Jim").And(Person.AddressID.LessThan(SQLNow)))
+	actual, values, err := query.SQL(0, 0)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"Jim"}, values)
+	assert.Equal(`SELECT "person"."id" FROM "person" AS "person" `+
+		`WHERE ("person"."name" IS ? AND "person"."address_id" < CURRENT_TIMESTAMP)`, actual)
+}
+
+func TestSelectDialectSQL(t *testing.T) {
+	assert := assert.New(t)
+	query := Select(Person.ID).From(Person).Where(Person.Name.Equal("Jim"))
+	actual, values, err := query.DialectSQL(Postgres, 0, 0)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"Jim"}, values)
+	assert.Equal(`SELECT "person"."id" FROM "person" AS "person" WHERE "person"."name" = $1`, actual)
+
+	// the dialect of the query is unchanged
+	actual, _, err = query.SQL(0, 0)
+	assert.NoError(err)
+	assert.Equal("SELECT `person`.`id` FROM `person` AS `person` WHERE `person`.`name` = ?", actual)
+}
+
+func TestInsertPostgresOnDuplicate(t *testing.T) {
+	assert := assert.New(t)
+	query := Insert(Person.ID, Person.Name).Values(1, "Jim").OnDuplicate([]TableField{Person.Name}, "Jim")
+	query.Dialect(Postgres)
+	_, _, err := query.SQL()
+	assert.EqualError(err, "on duplicate requires conflict keys for this dialect")
+
+	query.OnConflict(Person.ID)
+	actual, values, err := query.SQL()
+	assert.NoError(err)
+	assert.Equal([]interface{}{1, "Jim", "Jim"}, values)
+	assert.Equal(`INSERT INTO "person" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = $3`,
+		actual)
+
+	actual, err = query.ParameterizedSQL()
+	assert.NoError(err)
+	assert.Equal(`INSERT INTO "person" ("id", "name") VALUES (:id, :name) ON CONFLICT ("id") DO UPDATE SET "name" = :name`,
+		actual)
+}
+
+func TestInsertMySQLOnConflictIgnored(t *testing.T) {
+	assert := assert.New(t)
+	query := Insert(Person.ID, Person.Name).OnDuplicate([]TableField{Person.Name}).OnConflict(Person.ID)
+	actual, err := query.ParameterizedSQL()
+	assert.NoError(err)
+	assert.Equal("INSERT INTO `person` (`person`.`id`, `person`.`name`) VALUES (:id, :name) "+
+		"ON DUPLICATE KEY UPDATE `person`.`name` = :name", actual)
+}
+
+func TestUpdateSQLite(t *testing.T) {
+	assert := assert.New(t)
+	query := Update(Person).Dialect(SQLite).Set(Person.Name, "Jim").SetParam(Person.AddressID)
+	query.Where(Person.ID.Equal(3))
+	actual, values, err := query.SQL(NoLimit)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"Jim", 3}, values)
+	assert.Equal(`UPDATE "person" SET  "name" = ?, "address_id" = :address_id WHERE "person"."id" = ?`, actual)
+}
+
+func TestDeletePostgres(t *testing.T) {
+	assert := assert.New(t)
+	query := Delete(Person).Dialect(Postgres).Where(Person.ID.In(1, 2))
+	actual, values, err := query.SQL()
+	assert.NoError(err)
+	assert.Equal([]interface{}{1, 2}, values)
+	assert.Equal(`DELETE FROM "person" WHERE "person"."id" IN ($1, $2)`, actual)
+}
@@ -13,17 +13,30 @@ const (
 	SQLNull = "NULL"
 )
 
+// operandExpression is an expression, other than a TableField, that can be used in a comparison
+type operandExpression interface {
+	// GetTables that are used in this expression
+	GetTables() []string
+	operand(dialect Dialect) (string, []interface{})
+}
+
 type expressionUnion struct {
-	value interface{}
-	field *TableField
-	multi []expressionUnion
+	value      interface{}
+	field      *TableField
+	query      *SelectQuery
+	expression operandExpression
+	multi      []expressionUnion
 }
 
 func newUnion(values ...interface{}) expressionUnion {
 	if len(values) == 1 {
-		tf, ok := values[0].(TableField)
-		if ok {
-			return expressionUnion{field: &tf}
+		switch v := values[0].(type) {
+		case TableField:
+			return expressionUnion{field: &v}
+		case *SelectQuery:
+			return expressionUnion{query: v}
+		case operandExpression:
+			return expressionUnion{expression: v}
 		}
 		return expressionUnion{value: values[0]}
 	}
@@ -47,9 +60,23 @@ func (union expressionUnion) isMulti() bool {
 	return nil != union.multi
 }
 
+func (union expressionUnion) isQuery() bool {
+	return nil != union.query
+}
+
+func (union expressionUnion) isExpression() bool {
+	return nil != union.expression
+}
+
 func (union expressionUnion) getTables() []string {
 	if union.isField() {
 		return union.field.GetTables()
+	} else if union.isQuery() {
+		// tables the subquery does not provide itself must come from the enclosing query
+		tables, _ := union.query.missingTables()
+		return tables
+	} else if union.isExpression() {
+		return union.expression.GetTables()
 	} else if union.isMulti() {
 		tables := []string{}
 		for _, exp := range union.multi {
@@ -61,20 +88,38 @@ func (union expressionUnion) getTables() []string {
 	}
 }
 
-func (union expressionUnion) sql() (string, []interface{}) {
+func (union expressionUnion) subqueries() []*SelectQuery {
+	queries := []*SelectQuery{}
+	if union.isQuery() {
+		queries = append(queries, union.query)
+	}
+	for _, exp := range union.multi {
+		queries = append(queries, exp.subqueries()...)
+	}
+	return queries
+}
+
+func (union expressionUnion) sql(dialect Dialect) (string, []interface{}) {
 	var sql string
 	values := []interface{}{}
 	if union.isMulti() {
 		sa := make([]string, len(union.multi))
 		var subvalues []interface{}
 		for i, exp := range union.multi {
-			sa[i], subvalues = exp.sql()
+			sa[i], subvalues = exp.sql(dialect)
 			values = append(values, subvalues...)
 		}
 		sql = "(" + strings.Join(sa, ", ") + ")"
 	} else if union.isField() {
 		sql = union.field.SQL()
-	} else if SQLNow == union.value || SQLNull == union.value {
+	} else if union.isQuery() {
+		sql, values = union.query.render(dialect, NoLimit, 0)
+		sql = "(" + sql + ")"
+	} else if union.isExpression() {
+		sql, values = union.expression.operand(dialect)
+	} else if SQLNow == union.value {
+		sql = dialect.Now()
+	} else if SQLNull == union.value {
 		sql = fmt.Sprintf("%s", union.value)
 	} else if union.isString() && strings.HasPrefix(union.value.(string), ":") {
 		sql = fmt.Sprintf("%s", union.value)
@@ -85,35 +130,35 @@ func (union expressionUnion) sql() (string, []interface{}) {
 	return sql, values
 }
 
-type comparisonExpression interface {
-	SQL() (string, []interface{})
-}
-
-type parameterExpression struct {
-	left       TableField
+type binaryExpression struct {
+	left       expressionUnion
 	comparison Comparison
+	right      expressionUnion
 }
 
-func (be parameterExpression) SQL() (string, []interface{}) {
-	left := be.left.SQL()
-	return fmt.Sprintf("%s %s :%s", left, be.comparison, be.left.GetName()), make([]interface{}, 0)
+func (be binaryExpression) sql(dialect Dialect) (string, []interface{}) {
+	left, values := be.left.sql(dialect)
+	right, rvalues := be.right.sql(dialect)
+	return dialect.Compare(left, be.comparison, right), append(values, rvalues...)
 }
 
-type binaryExpression struct {
-	left       TableField
-	comparison Comparison
-	right      expressionUnion
+type unaryExpression struct {
+	operator string
+	operand  expressionUnion
 }
 
-func (be binaryExpression) SQL() (string, []interface{}) {
-	left := be.left.SQL()
-	right, values := be.right.sql()
-	return fmt.Sprintf("%s %s %s", left, be.comparison, right), values
+func (ue unaryExpression) sql(dialect Dialect) (string, []interface{}) {
+	operand, values := ue.operand.sql(dialect)
+	if "" == ue.operator {
+		return operand, values
+	}
+	return fmt.Sprintf("%s %s", ue.operator, operand), values
 }
 
 // ConditionExpression represents an expression that can be used as a condition in a where or join on.
 type ConditionExpression struct {
 	binary   *binaryExpression
+	unary    *unaryExpression
 	left     *ConditionExpression
 	operator string
 	right    *ConditionExpression
@@ -123,8 +168,10 @@ type ConditionExpression struct {
 func (exp *ConditionExpression) Tables() []string {
 	tables := []string{}
 	if nil != exp.binary {
-		tables = append(tables, exp.binary.left.GetTables()...)
+		tables = append(tables, exp.binary.left.getTables()...)
 		tables = append(tables, exp.binary.right.getTables()...)
+	} else if nil != exp.unary {
+		tables = append(tables, exp.unary.operand.getTables()...)
 	} else {
 		tables = append(tables, exp.left.Tables()...)
 		tables = append(tables, exp.right.Tables()...)
@@ -132,16 +179,53 @@ func (exp *ConditionExpression) Tables() []string {
 	return tables
 }
 
-// FieldComparison to another field or a discrete value.
+// subqueries used in this expression or it's sub expressions.
+func (exp *ConditionExpression) subqueries() []*SelectQuery {
+	if nil != exp.binary {
+		return append(exp.binary.left.subqueries(), exp.binary.right.subqueries()...)
+	} else if nil != exp.unary {
+		return exp.unary.operand.subqueries()
+	}
+	return append(exp.left.subqueries(), exp.right.subqueries()...)
+}
+
+// FieldComparison to another field, a discrete value or a subquery.
 func FieldComparison(left TableField, comparison Comparison, right interface{}) *ConditionExpression {
+	return compare(left, comparison, right)
+}
+
+func compare(left interface{}, comparison Comparison, right interface{}) *ConditionExpression {
 	if nil == right {
 		right = SQLNull
 	}
-	return &ConditionExpression{binary: &binaryExpression{left: left, comparison: comparison, right: newUnion(right)}}
+	return &ConditionExpression{binary: &binaryExpression{left: newUnion(left), comparison: comparison,
+		right: newUnion(right)}}
 }
 
-// FieldIn a series of TableFields and/or values
+// FieldIn a series of TableFields and/or values, or the results of a subquery
 func FieldIn(left TableField, in ...interface{}) *ConditionExpression {
+	return fieldIn(left, In, Equal, in)
+}
+
+// FieldNotIn a series of TableFields and/or values, or the results of a subquery. A NOT IN that contains NULL is never
+// true so nil values are removed and instead the field must not be NULL.
+func FieldNotIn(left TableField, in ...interface{}) *ConditionExpression {
+	values := make([]interface{}, 0, len(in))
+	for _, value := range in {
+		if nil != value {
+			values = append(values, value)
+		}
+	}
+	if len(values) == len(in) {
+		return fieldIn(left, NotIn, NotEqual, in)
+	}
+	if len(values) == 0 {
+		return left.IsNotNull()
+	}
+	return AllOf(fieldIn(left, NotIn, NotEqual, values), left.IsNotNull())
+}
+
+func fieldIn(left TableField, comparison Comparison, single Comparison, in []interface{}) *ConditionExpression {
 	// swap any 'nils' for sql null
 	rightValues := make([]interface{}, len(in))
 	for i, value := range in {
@@ -150,11 +234,39 @@ func FieldIn(left TableField, in ...interface{}) *ConditionExpression {
 		}
 		rightValues[i] = value
 	}
-	comparison := In
 	if len(rightValues) == 1 {
-		comparison = Equal
+		if _, ok := rightValues[0].(*SelectQuery); !ok {
+			comparison = single
+		}
 	}
-	return &ConditionExpression{binary: &binaryExpression{left: left, comparison: comparison, right: newUnion(rightValues...)}}
+	return &ConditionExpression{binary: &binaryExpression{left: newUnion(left), comparison: comparison,
+		right: newUnion(rightValues...)}}
+}
+
+// FieldBetween the low and high values inclusive.
+func FieldBetween(left TableField, low, high interface{}) *ConditionExpression {
+	return compare(left, Between, valueRange{low: newUnion(low), high: newUnion(high)})
+}
+
+// FieldMatch creates a MySQL full text search expression of the fields against the search. The fields must match
+// the columns of a FULLTEXT index.
+func FieldMatch(fields []TableField, search string, mode MatchMode) *ConditionExpression {
+	return compare(matchFields(fields), Against, againstSearch{search: search, mode: mode})
+}
+
+// Exists creates an expression that is true when the subquery returns any rows.
+func Exists(query *SelectQuery) *ConditionExpression {
+	return &ConditionExpression{unary: &unaryExpression{operator: "EXISTS", operand: newUnion(query)}}
+}
+
+// NotExists creates an expression that is true when the subquery returns no rows.
+func NotExists(query *SelectQuery) *ConditionExpression {
+	return &ConditionExpression{unary: &unaryExpression{operator: "NOT EXISTS", operand: newUnion(query)}}
+}
+
+// RawCondition creates a condition from vetted SQL with bound arguments. The SQL is included in the query as is.
+func RawCondition(sql string, args ...interface{}) *ConditionExpression {
+	return &ConditionExpression{unary: &unaryExpression{operand: newUnion(Raw(sql, args...))}}
 }
 
 // And creates an expression with this and the passed expression with an AND conjunction.
@@ -184,13 +296,152 @@ func (exp *ConditionExpression) XOr(expression *ConditionExpression) *ConditionE
 	return exp
 }
 
+// AllOf creates an expression with the passed expressions joined with AND conjunctions. Unlike And the passed
+// expressions are not modified so they can be reused, nil expressions are ignored.
+func AllOf(expressions ...*ConditionExpression) *ConditionExpression {
+	var all *ConditionExpression
+	for _, expression := range expressions {
+		if nil == expression {
+			continue
+		}
+		if nil == all {
+			all = expression
+		} else {
+			all = &ConditionExpression{left: all, right: expression, operator: And}
+		}
+	}
+	return all
+}
+
 // SQL returns this condition expression as a SQL expression.
 func (exp *ConditionExpression) SQL() (string, []interface{}) {
+	return exp.sql(MySQL)
+}
+
+func (exp *ConditionExpression) sql(dialect Dialect) (string, []interface{}) {
 	if nil != exp.binary {
-		return exp.binary.SQL()
+		return exp.binary.sql(dialect)
+	} else if nil != exp.unary {
+		return exp.unary.sql(dialect)
 	}
-	lsql, values := exp.left.SQL()
-	rsql, rvalues := exp.right.SQL()
+	lsql, values := exp.left.sql(dialect)
+	rsql, rvalues := exp.right.sql(dialect)
 	values = append(values, rvalues...)
-	return fmt.Sprintf("(%s %s %s)", lsql, exp.operator, rsql), values
+	return dialect.Conjoin(lsql, exp.operator, rsql), values
+}
+
+// EscapeLike escapes the LIKE wildcards in the passed value so that it matches literally.
+func EscapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// valueRange is the low and high values of a BETWEEN comparison
+type valueRange struct {
+	low  expressionUnion
+	high expressionUnion
+}
+
+func (vr valueRange) GetTables() []string {
+	return append(vr.low.getTables(), vr.high.getTables()...)
+}
+
+func (vr valueRange) operand(dialect Dialect) (string, []interface{}) {
+	low, values := vr.low.sql(dialect)
+	high, hvalues := vr.high.sql(dialect)
+	return fmt.Sprintf("%s AND %s", low, high), append(values, hvalues...)
+}
+
+// MatchMode for a full text search
+type MatchMode string
+
+const (
+	// NaturalLanguage full text search mode
+	NaturalLanguage MatchMode = "IN NATURAL LANGUAGE MODE"
+	// Boolean full text search mode
+	Boolean MatchMode = "IN BOOLEAN MODE"
+	// QueryExpansion full text search mode
+	QueryExpansion MatchMode = "WITH QUERY EXPANSION"
+)
+
+// matchFields is the MATCH portion of a full text search
+type matchFields []TableField
+
+func (mf matchFields) GetTables() []string {
+	tables := []string{}
+	for _, field := range mf {
+		tables = append(tables, field.GetTables()...)
+	}
+	return tables
+}
+
+func (mf matchFields) operand(dialect Dialect) (string, []interface{}) {
+	columns := make([]string, len(mf))
+	for i, field := range mf {
+		columns[i] = field.SQL()
+	}
+	return fmt.Sprintf("MATCH (%s)", strings.Join(columns, ", ")), []interface{}{}
+}
+
+// againstSearch is the AGAINST portion of a full text search
+type againstSearch struct {
+	search string
+	mode   MatchMode
+}
+
+func (as againstSearch) GetTables() []string {
+	return []string{}
+}
+
+func (as againstSearch) operand(dialect Dialect) (string, []interface{}) {
+	if "" == as.mode {
+		return "(?)", []interface{}{as.search}
+	}
+	return fmt.Sprintf("(? %s)", as.mode), []interface{}{as.search}
+}
+
+// Expression is vetted SQL with bound arguments for use where the query builder has no support. The SQL is included
+// in the query as is, it should use '?' placeholders for the arguments.
+type Expression struct {
+	sql   string
+	args  []interface{}
+	alias string
+}
+
+// Raw creates an expression from vetted SQL with bound arguments for use as a value or SelectExpression.
+func Raw(sql string, args ...interface{}) *Expression {
+	return &Expression{sql: sql, args: args}
+}
+
+// As returns a copy of this expression with the passed alias for use as a SelectExpression.
+func (exp *Expression) As(alias string) *Expression {
+	return &Expression{sql: exp.sql, args: exp.args, alias: alias}
+}
+
+// GetName that can be used to reference this expression
+func (exp *Expression) GetName() string {
+	return exp.alias
+}
+
+// GetTables that are used in this expression, the tables used in raw SQL are not validated.
+func (exp *Expression) GetTables() []string {
+	return []string{}
+}
+
+// SQL that represents this expression as a SelectExpression
+func (exp *Expression) SQL() string {
+	if "" == exp.alias {
+		return exp.sql
+	}
+	return fmt.Sprintf("%s AS `%s`", exp.sql, exp.alias)
+}
+
+// Values bound to this expression
+func (exp *Expression) Values() []interface{} {
+	return exp.args
+}
+
+func (exp *Expression) operand(dialect Dialect) (string, []interface{}) {
+	return exp.sql, exp.args
 }
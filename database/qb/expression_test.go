@@ -54,6 +54,21 @@ func TestExpressionAnd(t *testing.T) {
 	assert.Equal("((`person`.`address_id` = `address`.`id` AND `address`.`line` IS NOT NULL) AND (`person`.`address_id` = `address`.`id` AND `address`.`line` = `person`.`id`))", actual)
 }
 
+func TestAllOf(t *testing.T) {
+	assert := assert.New(t)
+	left := FieldComparison(Person.AddressID, Equal, Address.ID)
+	right := FieldComparison(Address.Line, IsNot, nil)
+	actual, values := AllOf(left, nil, right).SQL()
+	assert.Empty(values)
+	assert.Equal("(`person`.`address_id` = `address`.`id` AND `address`.`line` IS NOT NULL)", actual)
+
+	// the expressions are not modified
+	actual, _ = left.SQL()
+	assert.Equal("`person`.`address_id` = `address`.`id`", actual)
+	assert.Equal(left, AllOf(nil, left))
+	assert.Nil(AllOf())
+}
+
 func TestExpressionOr(t *testing.T) {
 	assert := assert.New(t)
 	expression := FieldComparison(Person.AddressID, Equal, Address.ID).Or(FieldComparison(Address.Line, IsNot, nil))
@@ -133,3 +148,103 @@ func TestExpressionGetTables(t *testing.T) {
 	assert.Equal(4, len(actual))
 	assert.Equal([]string{"person", "address", "person", "address"}, actual)
 }
+
+func TestExpressionSubquery(t *testing.T) {
+	assert := assert.New(t)
+	subquery := Select(Address.ID).From(Address).Where(Address.Country.Equal("US"))
+	expression := FieldIn(Person.AddressID, subquery).Or(Exists(subquery))
+	actual, values := expression.SQL()
+	assert.Equal([]interface{}{"US", "US"}, values)
+	assert.Equal("(`person`.`address_id` IN (SELECT `address`.`id` FROM `address` AS `address` WHERE `address`.`country` = ?) "+
+		"OR EXISTS (SELECT `address`.`id` FROM `address` AS `address` WHERE `address`.`country` = ?))", actual)
+	assert.Equal([]string{"person"}, expression.Tables())
+}
+
+func TestExpressionRaw(t *testing.T) {
+	assert := assert.New(t)
+	query := Select(Person.ID, Raw("LENGTH(`person`.`name`) + ?", 1).As("name_length")).
+		From(Person).
+		Where(RawCondition("LENGTH(`person`.`name`) > ?", 3).And(Person.AddressID.Equal(Raw("ABS(?)", -4)))).
+		GroupBy(Raw("LEFT(`person`.`name`, ?)", 1)).
+		Dialect(Postgres)
+	actual, values, err := query.SQL(0, 0)
+	assert.NoError(err)
+	assert.Equal([]interface{}{1, 3, -4, 1}, values)
+	assert.Equal(`SELECT "person"."id", LENGTH("person"."name") + $1 AS "name_length" FROM "person" AS "person" `+
+		`WHERE (LENGTH("person"."name") > $2 AND "person"."address_id" = ABS($3)) GROUP BY LEFT("person"."name", $4)`,
+		actual)
+}
+
+func TestExpressionLike(t *testing.T) {
+	assert := assert.New(t)
+	expression := Person.Name.Like("J_m%").And(Person.Name.NotLike(Address.Line))
+	actual, values := expression.SQL()
+	assert.Equal([]interface{}{"J_m%"}, values)
+	assert.Equal("(`person`.`name` LIKE ? AND `person`.`name` NOT LIKE `address`.`line`)", actual)
+	assert.Equal([]string{"person", "person", "address"}, expression.Tables())
+}
+
+func TestExpressionLikeEscaped(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(`50\% off\_now \\o/`, EscapeLike(`50% off_now \o/`))
+
+	expression := Person.Name.Contains("50%").Or(Person.Name.StartsWith("a_")).Or(Person.Name.EndsWith(`\`))
+	actual, values := expression.SQL()
+	assert.Equal([]interface{}{`%50\%%`, `a\_%`, `%\\`}, values)
+	assert.Equal("((`person`.`name` LIKE ? OR `person`.`name` LIKE ?) OR `person`.`name` LIKE ?)", actual)
+
+	query := Select(Person.ID).From(Person).Where(Person.Name.Contains("50%")).Dialect(SQLite)
+	sql, _, err := query.SQL(0, 0)
+	assert.NoError(err)
+	assert.Equal(`SELECT "person"."id" FROM "person" AS "person" WHERE "person"."name" LIKE ? ESCAPE '\'`, sql)
+}
+
+func TestExpressionBetween(t *testing.T) {
+	assert := assert.New(t)
+	expression := Person.ID.Between(1, Address.ID).And(Person.AddressID.NotBetween(SQLNow, 10))
+	actual, values := expression.SQL()
+	assert.Equal([]interface{}{1, 10}, values)
+	assert.Equal("(`person`.`id` BETWEEN ? AND `address`.`id` AND `person`.`address_id` NOT BETWEEN NOW() AND ?)", actual)
+	assert.Equal([]string{"person", "address", "person"}, expression.Tables())
+}
+
+func TestExpressionNotIn(t *testing.T) {
+	assert := assert.New(t)
+	actual, values := Person.ID.NotIn(1, 2).SQL()
+	assert.Equal([]interface{}{1, 2}, values)
+	assert.Equal("`person`.`id` NOT IN (?, ?)", actual)
+
+	// a NULL in the list would never match so the field is required to be NOT NULL instead
+	actual, values = Person.ID.NotIn(1, 2, nil).SQL()
+	assert.Equal([]interface{}{1, 2}, values)
+	assert.Equal("(`person`.`id` NOT IN (?, ?) AND `person`.`id` IS NOT NULL)", actual)
+
+	actual, values = Person.ID.NotIn(1, nil).SQL()
+	assert.Equal([]interface{}{1}, values)
+	assert.Equal("(`person`.`id` != ? AND `person`.`id` IS NOT NULL)", actual)
+
+	actual, values = Person.ID.NotIn(nil).SQL()
+	assert.Empty(values)
+	assert.Equal("`person`.`id` IS NOT NULL", actual)
+
+	actual, values = Person.ID.NotIn(1).SQL()
+	assert.Equal([]interface{}{1}, values)
+	assert.Equal("`person`.`id` != ?", actual)
+
+	actual, values = Person.ID.NotIn(Select(Address.ID).From(Address)).SQL()
+	assert.Empty(values)
+	assert.Equal("`person`.`id` NOT IN (SELECT `address`.`id` FROM `address` AS `address`)", actual)
+}
+
+func TestExpressionMatch(t *testing.T) {
+	assert := assert.New(t)
+	actual, values := Person.Name.Match("jim", NaturalLanguage).SQL()
+	assert.Equal([]interface{}{"jim"}, values)
+	assert.Equal("MATCH (`person`.`name`) AGAINST (? IN NATURAL LANGUAGE MODE)", actual)
+
+	expression := FieldMatch([]TableField{Address.Line, Address.Line2}, "+main -st", Boolean)
+	actual, values = expression.SQL()
+	assert.Equal([]interface{}{"+main -st"}, values)
+	assert.Equal("MATCH (`address`.`line`, `address`.`line2`) AGAINST (? IN BOOLEAN MODE)", actual)
+	assert.Equal([]string{"address", "address"}, expression.Tables())
+}
@@ -13,6 +13,9 @@ type InsertQuery struct {
 	values            [][]interface{}
 	onDuplicate       []TableField
 	onDuplicateValues []interface{}
+	onDuplicateInsert []TableField
+	conflict          []TableField
+	dialect           Dialect
 	err               error
 }
 
@@ -33,6 +36,26 @@ func (q *InsertQuery) OnDuplicate(fields []TableField, values ...interface{}) *I
 	return q
 }
 
+// OnDuplicateInserted updates these fields to the values that would have been inserted. Unlike OnDuplicate this can
+// be used with a multi-row insert.
+func (q *InsertQuery) OnDuplicateInserted(fields ...TableField) *InsertQuery {
+	q.onDuplicateInsert = append(q.onDuplicateInsert, fields...)
+	return q
+}
+
+// OnConflict sets the key columns that identify a duplicate row. Required for an OnDuplicate update in dialects
+// other than MySQL.
+func (q *InsertQuery) OnConflict(keys ...TableField) *InsertQuery {
+	q.conflict = append(q.conflict, keys...)
+	return q
+}
+
+// Dialect sets the database dialect used to render this query, defaults to MySQL.
+func (q *InsertQuery) Dialect(dialect Dialect) *InsertQuery {
+	q.dialect = dialect
+	return q
+}
+
 // GetAlias of the passed table name in this query.
 func (q *InsertQuery) GetAlias(tableName string) string {
 	return tableName
@@ -43,10 +66,11 @@ func (q *InsertQuery) SQL() (string, []interface{}, error) {
 	if len(q.columns) == 0 {
 		return "", nil, errors.New("no columns specified for insert")
 	}
+	dialect := dialectOrDefault(q.dialect)
 	colExp := make([]string, len(q.columns))
 	qms := make([]string, len(q.columns))
 	for i, col := range q.columns {
-		colExp[i] = col.SQL()
+		colExp[i] = assignmentColumn(dialect, col)
 		if col.Table != q.columns[0].Table {
 			return "", nil, errors.New("insert columns must be from the same table")
 		}
@@ -59,25 +83,23 @@ func (q *InsertQuery) SQL() (string, []interface{}, error) {
 		valExps[i] = valExp
 		values = append(values, valGrp...)
 	}
-	onDuplicate := ""
-	if len(q.onDuplicate) > 0 {
-		if len(q.values) > 1 {
-			return "", nil, errors.New("cannot use on duplicate with multi-insert")
-		}
-		updateFields := make([]string, len(q.onDuplicate))
-		for _, col := range q.onDuplicate {
-			if col.Table != q.columns[0].Table {
-				return "", nil, errors.New("insert columns must be from the same table")
-			}
-			for i, col := range q.onDuplicate {
-				updateFields[i] = fmt.Sprintf("%s = ?", col.SQL())
-			}
+	if len(q.onDuplicate) > 0 && len(q.values) > 1 {
+		return "", nil, errors.New("cannot use on duplicate with multi-insert")
+	}
+	updateFields := make([]string, 0, len(q.onDuplicate)+len(q.onDuplicateInsert))
+	for _, col := range q.onDuplicate {
+		if col.Table != q.columns[0].Table {
+			return "", nil, errors.New("insert columns must be from the same table")
 		}
-		values = append(values, q.onDuplicateValues...)
-		onDuplicate = " ON DUPLICATE KEY UPDATE " + strings.Join(updateFields, ", ")
+		updateFields = append(updateFields, fmt.Sprintf("%s = ?", assignmentColumn(dialect, col)))
+	}
+	values = append(values, q.onDuplicateValues...)
+	onDuplicate, err := q.upsertSQL(dialect, updateFields)
+	if nil != err {
+		return "", nil, err
 	}
-	return fmt.Sprintf("INSERT INTO `%s` (%s) VALUES %s%s", q.columns[0].Table, strings.Join(colExp, ", "),
-		strings.Join(valExps, ", "), onDuplicate), values, q.err
+	return bind(dialect, fmt.Sprintf("INSERT INTO `%s` (%s) VALUES %s%s", q.columns[0].Table,
+		strings.Join(colExp, ", "), strings.Join(valExps, ", "), onDuplicate)), values, q.err
 }
 
 // ParameterizedSQL that represents this insert query.
@@ -85,26 +107,55 @@ func (q *InsertQuery) ParameterizedSQL() (string, error) {
 	if len(q.columns) == 0 {
 		return "", errors.New("no columns specified for insert")
 	}
+	dialect := dialectOrDefault(q.dialect)
 	colExp := make([]string, len(q.columns))
 	qms := make([]string, len(q.columns))
 	for i, col := range q.columns {
-		colExp[i] = col.SQL()
+		colExp[i] = assignmentColumn(dialect, col)
 		if col.Table != q.columns[0].Table {
 			return "", errors.New("insert columns must be from the same table")
 		}
 		qms[i] = ":" + col.GetName()
 	}
-	onDuplicate := ""
-	if len(q.onDuplicate) > 0 {
-		if len(q.values) > 1 {
-			return "", errors.New("cannot use on duplicate with multi-insert")
-		}
-		updateFields := make([]string, len(q.onDuplicate))
-		for i, field := range q.onDuplicate {
-			updateFields[i] = fmt.Sprintf("%s = :%s", field.SQL(), field.GetName())
+	if len(q.onDuplicate) > 0 && len(q.values) > 1 {
+		return "", errors.New("cannot use on duplicate with multi-insert")
+	}
+	updateFields := make([]string, 0, len(q.onDuplicate)+len(q.onDuplicateInsert))
+	for _, field := range q.onDuplicate {
+		updateFields = append(updateFields, fmt.Sprintf("%s = :%s", assignmentColumn(dialect, field), field.GetName()))
+	}
+	onDuplicate, err := q.upsertSQL(dialect, updateFields)
+	if nil != err {
+		return "", err
+	}
+	return bind(dialect, fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)%s", q.columns[0].Table,
+		strings.Join(colExp, ", "), strings.Join(qms, ", "), onDuplicate)), q.err
+}
+
+// upsertSQL appends the assignments for the inserted values to the passed assignments and returns the resulting
+// upsert clause, or an empty string if there is nothing to update.
+func (q *InsertQuery) upsertSQL(dialect Dialect, updateFields []string) (string, error) {
+	for _, col := range q.onDuplicateInsert {
+		if col.Table != q.columns[0].Table {
+			return "", errors.New("insert columns must be from the same table")
 		}
-		onDuplicate = " ON DUPLICATE KEY UPDATE " + strings.Join(updateFields, ", ")
+		updateFields = append(updateFields, fmt.Sprintf("%s = %s", assignmentColumn(dialect, col),
+			dialect.Inserted(col.Name)))
+	}
+	if len(updateFields) == 0 {
+		return "", nil
+	}
+	upsert, err := dialect.Upsert(q.conflictKeys(), updateFields)
+	if nil != err {
+		return "", err
+	}
+	return " " + upsert, nil
+}
+
+func (q *InsertQuery) conflictKeys() []string {
+	keys := make([]string, len(q.conflict))
+	for i, key := range q.conflict {
+		keys[i] = fmt.Sprintf("`%s`", key.Name)
 	}
-	return fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)%s", q.columns[0].Table, strings.Join(colExp, ", "),
-		strings.Join(qms, ", "), onDuplicate), q.err
+	return keys
 }
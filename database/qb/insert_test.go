@@ -107,3 +107,20 @@ func TestInsertQueryMultiOnDuplicate(t *testing.T) {
 	assert.EqualError(err, "cannot use on duplicate with multi-insert")
 	assert.Equal("", sql)
 }
+
+func TestInsertQueryMultiOnDuplicateInserted(t *testing.T) {
+	assert := assert.New(t)
+	query := Insert(Person.ID, Person.Name).Values(1, "Jim").Values(2, "Bob").OnDuplicateInserted(Person.Name)
+	sql, values, err := query.SQL()
+	assert.NoError(err)
+	assert.Equal([]interface{}{1, "Jim", 2, "Bob"}, values)
+	assert.Equal("INSERT INTO `person` (`person`.`id`, `person`.`name`) VALUES (?, ?), (?, ?) "+
+		"ON DUPLICATE KEY UPDATE `person`.`name` = VALUES(`name`)", sql)
+
+	query.Dialect(Postgres).OnConflict(Person.ID)
+	sql, values, err = query.SQL()
+	assert.NoError(err)
+	assert.Equal([]interface{}{1, "Jim", 2, "Bob"}, values)
+	assert.Equal(`INSERT INTO "person" ("id", "name") VALUES ($1, $2), ($3, $4) `+
+		`ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`, sql)
+}
@@ -81,6 +81,18 @@ const (
 	IsNot Comparison = "IS NOT"
 	// In Comparison Operator
 	In Comparison = "IN"
+	// NotIn Comparison Operator
+	NotIn Comparison = "NOT IN"
+	// Like Comparison Operator
+	Like Comparison = "LIKE"
+	// NotLike Comparison Operator
+	NotLike Comparison = "NOT LIKE"
+	// Between Comparison Operator
+	Between Comparison = "BETWEEN"
+	// NotBetween Comparison Operator
+	NotBetween Comparison = "NOT BETWEEN"
+	// Against Comparison Operator for MySQL full text MATCH ... AGAINST
+	Against Comparison = "AGAINST"
 	// Inner JoinType
 	Inner JoinType = "INNER"
 	// Outer JoinType
@@ -123,6 +135,29 @@ type Table interface {
 	SortBy() (TableField, OrderDirection)
 }
 
+// VersionedTable is a Table with a version column used for optimistic concurrency control. The version must be an
+// integer, it is incremented on each update.
+type VersionedTable interface {
+	Table
+	// Version returns the version TableField
+	Version() TableField
+}
+
+// SoftDeleteTable is a Table whose rows are marked as deleted by setting a column rather than being removed.
+type SoftDeleteTable interface {
+	Table
+	// SoftDelete returns the TableField set to the time the row was deleted, NULL while the row is not deleted
+	SoftDelete() TableField
+}
+
+// IndexedTable is a Table that declares the indexes it expects to exist, each index is the list of its columns in order.
+// The primary key is always expected to be indexed.
+type IndexedTable interface {
+	Table
+	// Indexes returns the columns of each index of the Table
+	Indexes() [][]TableField
+}
+
 // TableField represents a single column on a table.
 type TableField struct {
 	// Name of the column in the database table
@@ -189,6 +224,51 @@ func (tf TableField) In(objs ...interface{}) *ConditionExpression {
 	return FieldIn(tf, objs...)
 }
 
+// NotIn returns a condition expression for this table field not in the passed objs.
+func (tf TableField) NotIn(objs ...interface{}) *ConditionExpression {
+	return FieldNotIn(tf, objs...)
+}
+
+// Like returns a condition expression for this table field matching the passed pattern, '%' and '_' are wildcards.
+func (tf TableField) Like(pattern interface{}) *ConditionExpression {
+	return FieldComparison(tf, Like, pattern)
+}
+
+// NotLike returns a condition expression for this table field not matching the passed pattern.
+func (tf TableField) NotLike(pattern interface{}) *ConditionExpression {
+	return FieldComparison(tf, NotLike, pattern)
+}
+
+// Contains returns a condition expression for this table field containing the passed literal value.
+func (tf TableField) Contains(value string) *ConditionExpression {
+	return FieldComparison(tf, Like, "%"+EscapeLike(value)+"%")
+}
+
+// StartsWith returns a condition expression for this table field starting with the passed literal value.
+func (tf TableField) StartsWith(value string) *ConditionExpression {
+	return FieldComparison(tf, Like, EscapeLike(value)+"%")
+}
+
+// EndsWith returns a condition expression for this table field ending with the passed literal value.
+func (tf TableField) EndsWith(value string) *ConditionExpression {
+	return FieldComparison(tf, Like, "%"+EscapeLike(value))
+}
+
+// Between returns a condition expression for this table field between the passed low and high values inclusive.
+func (tf TableField) Between(low, high interface{}) *ConditionExpression {
+	return FieldBetween(tf, low, high)
+}
+
+// NotBetween returns a condition expression for this table field outside of the passed low and high values.
+func (tf TableField) NotBetween(low, high interface{}) *ConditionExpression {
+	return compare(tf, NotBetween, valueRange{low: newUnion(low), high: newUnion(high)})
+}
+
+// Match returns a MySQL full text search condition expression for this table field against the passed search.
+func (tf TableField) Match(search string, mode MatchMode) *ConditionExpression {
+	return FieldMatch([]TableField{tf}, search, mode)
+}
+
 // IsNull returns a condition expression for this table field when it is NULL
 func (tf TableField) IsNull() *ConditionExpression {
 	return FieldComparison(tf, Is, SQLNull)
@@ -256,12 +336,12 @@ func (wc *whereCondition) tables() []string {
 	return tables
 }
 
-func (wc *whereCondition) sql() (string, []interface{}, bool) {
+func (wc *whereCondition) sql(dialect Dialect) (string, []interface{}, bool) {
 	var sql string
 	var values []interface{}
 	ok := false
 	if nil != wc.expression {
-		sql, values = wc.expression.SQL()
+		sql, values = wc.expression.sql(dialect)
 		ok = true
 	}
 	return sql, values, ok
@@ -309,21 +389,33 @@ func (join *Join) On(left TableField, comparison Comparison, right interface{})
 
 // SQL that represents this join.
 func (join *Join) SQL() (string, []interface{}) {
+	return join.sql(MySQL)
+}
+
+func (join *Join) sql(dialect Dialect) (string, []interface{}) {
 	if nil != join.err {
 		return "", []interface{}{}
 	}
 	var lines []string
+	table, values := tableReferenceSQL(dialect, join.table)
 	if join.joinType == Inner || join.joinType == Cross {
-		lines = []string{fmt.Sprintf("%s JOIN `%s` AS `%s` ON", join.joinType,
-			join.table.GetName(), join.table.GetAlias())}
+		lines = []string{fmt.Sprintf("%s JOIN %s ON", join.joinType, table)}
 	} else {
-		lines = []string{fmt.Sprintf("%s %s JOIN `%s` AS `%s` ON", join.direction, join.joinType, join.table.GetName(),
-			join.table.GetAlias())}
+		lines = []string{fmt.Sprintf("%s %s JOIN %s ON", join.direction, join.joinType, table)}
 	}
-	expressionSQL, values := join.condition.SQL()
+	expressionSQL, conditionValues := join.condition.sql(dialect)
 
 	lines = append(lines, expressionSQL)
-	return strings.Join(lines, " "), values
+	return strings.Join(lines, " "), append(values, conditionValues...)
+}
+
+// tableReferenceSQL for use in a from or join clause along with the values of a derived table.
+func tableReferenceSQL(dialect Dialect, table Table) (string, []interface{}) {
+	if derived, ok := table.(*DerivedTable); ok {
+		sql, values := derived.query.render(dialect, NoLimit, 0)
+		return fmt.Sprintf("(%s) AS `%s`", sql, derived.alias), values
+	}
+	return fmt.Sprintf("`%s` AS `%s`", table.GetName(), table.GetAlias()), []interface{}{}
 }
 
 // Select creates a new select query based on the passed expressions for the select clause.
@@ -333,6 +425,7 @@ func Select(selectExpressions ...SelectExpression) *SelectQuery {
 		orderBy:    &orderBy{},
 		groupBy:    []SelectExpression{},
 		where:      &whereCondition{},
+		having:     &whereCondition{},
 		Seperator:  " ",
 	}
 	for _, exp := range selectExpressions {
@@ -365,7 +458,7 @@ func Insert(columns ...TableField) *InsertQuery {
 func Update(table Table) *UpdateQuery {
 	return &UpdateQuery{
 		tableReference: table,
-		assignments:    []comparisonExpression{},
+		assignments:    []assignment{},
 		orderBy:        &orderBy{},
 		where:          &whereCondition{},
 	}
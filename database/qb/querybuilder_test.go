@@ -338,3 +338,88 @@ func TestSelectNotNull(t *testing.T) {
 	assert.Empty(values)
 	assert.Equal("SELECT (`person`.`id` IS NOT NULL) AS `person_id_not_null`, `person`.`name` FROM `person` AS `person`", actual)
 }
+
+func TestQueryBuilderSubqueryIn(t *testing.T) {
+	assert := assert.New(t)
+	subquery := Select(Address.ID).From(Address).Where(Address.Country.Equal("US"))
+	query := Select(Person.ID).From(Person).Where(Person.Name.NotEqual("Jim").And(Person.AddressID.In(subquery)))
+	actual, values, err := query.SQL(10, 0)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"Jim", "US"}, values)
+	assert.Equal("SELECT `person`.`id` FROM `person` AS `person` "+
+		"WHERE (`person`.`name` != ? AND `person`.`address_id` IN "+
+		"(SELECT `address`.`id` FROM `address` AS `address` WHERE `address`.`country` = ?)) "+
+		"LIMIT 10 OFFSET 0", actual)
+}
+
+func TestQueryBuilderSubqueryComparison(t *testing.T) {
+	assert := assert.New(t)
+	subquery := Select(Address.ID).From(Address).Where(Address.Line.Equal("1 Main St"))
+	query := Select(Person.ID).From(Person).Where(Person.AddressID.Equal(subquery)).Dialect(Postgres)
+	actual, values, err := query.SQL(0, 0)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"1 Main St"}, values)
+	assert.Equal(`SELECT "person"."id" FROM "person" AS "person" WHERE "person"."address_id" = `+
+		`(SELECT "address"."id" FROM "address" AS "address" WHERE "address"."line" = $1)`, actual)
+}
+
+func TestQueryBuilderCorrelatedSubquery(t *testing.T) {
+	assert := assert.New(t)
+	subquery := Select(Address.ID).From(Address).Where(Address.ID.Equal(Person.AddressID))
+	query := Select(Person.ID).From(Person).Where(Exists(subquery))
+	actual, values, err := query.SQL(0, 0)
+	assert.NoError(err)
+	assert.Empty(values)
+	assert.Equal("SELECT `person`.`id` FROM `person` AS `person` WHERE EXISTS "+
+		"(SELECT `address`.`id` FROM `address` AS `address` WHERE `address`.`id` = `person`.`address_id`)", actual)
+
+	// the subquery can not be used on it's own
+	_, _, err = subquery.SQL(0, 0)
+	assert.EqualError(err, NewMissingTablesError([]string{Person.GetName()}).Error())
+
+	// nor can it be used from a query that does not provide the table
+	other := Person.Alias("p")
+	query = Select(other.ID).From(other).Where(NotExists(subquery))
+	_, _, err = query.SQL(0, 0)
+	assert.EqualError(err, NewMissingTablesError([]string{Person.GetName()}).Error())
+}
+
+func TestQueryBuilderSubqueryFromNotSet(t *testing.T) {
+	assert := assert.New(t)
+	query := Select(Person.ID).From(Person).Where(Person.AddressID.In(Select(Address.ID)))
+	_, _, err := query.SQL(0, 0)
+	assert.EqualError(err, NewValidationFromNotSetError().Error())
+}
+
+func TestQueryBuilderDerivedTable(t *testing.T) {
+	assert := assert.New(t)
+	derived := Select(Alias(Person.ID, "person_id"), Person.AddressID).
+		From(Person).
+		Where(Person.Name.NotEqual("Jim")).
+		As("named")
+	query := Select(derived.Field("person_id"), Address.Line).From(derived)
+	query.InnerJoin(Address).On(Address.ID, Equal, derived.Field("address_id"))
+	query.Where(Address.Country.Equal("US"))
+	actual, values, err := query.SQL(5, 0)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"Jim", "US"}, values)
+	assert.Equal("SELECT `named`.`person_id`, `address`.`line` "+
+		"FROM (SELECT `person`.`id` AS `person_id`, `person`.`address_id` FROM `person` AS `person` "+
+		"WHERE `person`.`name` != ?) AS `named` "+
+		"INNER JOIN `address` AS `address` ON `address`.`id` = `named`.`address_id` "+
+		"WHERE `address`.`country` = ? LIMIT 5 OFFSET 0", actual)
+	assert.Equal([]TableField{derived.Field("person_id"), derived.Field("address_id")}, derived.ReadColumns())
+}
+
+func TestQueryBuilderJoinDerivedTable(t *testing.T) {
+	assert := assert.New(t)
+	derived := Select(Address.ID).From(Address).Where(Address.Country.Equal("US")).As("us")
+	query := Select(Person.ID).From(Person).Where(Person.Name.Equal("Jim"))
+	query.InnerJoin(derived).On(derived.Field("id"), Equal, Person.AddressID)
+	actual, values, err := query.SQL(0, 0)
+	assert.NoError(err)
+	assert.Equal([]interface{}{"US", "Jim"}, values)
+	assert.Equal("SELECT `person`.`id` FROM `person` AS `person` "+
+		"INNER JOIN (SELECT `address`.`id` FROM `address` AS `address` WHERE `address`.`country` = ?) AS `us` "+
+		"ON `us`.`id` = `person`.`address_id` WHERE `person`.`name` = ?", actual)
+}
@@ -0,0 +1,44 @@
+package qb
+
+// RelationType of a Relation between two tables
+type RelationType string
+
+const (
+	// HasMany Relation where the related table has a foreign key to this table
+	HasMany RelationType = "HAS_MANY"
+	// BelongsTo Relation where this table has a foreign key to the related table
+	BelongsTo RelationType = "BELONGS_TO"
+)
+
+// Relation of a Table to another Table through a foreign key
+type Relation struct {
+	// Name of the relation, the field of the parent struct that the related rows are assigned to
+	Name string
+	// Type of the relation
+	Type RelationType
+	// Local is the column of this table that is matched to the Foreign column
+	Local TableField
+	// Table that is related to this table
+	Table Table
+	// Foreign is the column of the related Table that is matched to the Local column
+	Foreign TableField
+}
+
+// NewHasMany relation where the foreign column of the related table references the local column of this table, usually
+// its primary key
+func NewHasMany(name string, local TableField, table Table, foreign TableField) Relation {
+	return Relation{Name: name, Type: HasMany, Local: local, Table: table, Foreign: foreign}
+}
+
+// NewBelongsTo relation where the local column of this table references the foreign column of the related table,
+// usually its primary key
+func NewBelongsTo(name string, local TableField, table Table, foreign TableField) Relation {
+	return Relation{Name: name, Type: BelongsTo, Local: local, Table: table, Foreign: foreign}
+}
+
+// RelatedTable is a Table with relations to other tables that can be preloaded
+type RelatedTable interface {
+	Table
+	// Relations returns the relations of the Table to other tables
+	Relations() []Relation
+}
@@ -91,6 +91,8 @@ type SelectQuery struct {
 	orderBy    *orderBy
 	groupBy    []SelectExpression
 	where      *whereCondition
+	having     *whereCondition
+	dialect    Dialect
 	Seperator  string
 	err        error
 }
@@ -100,7 +102,23 @@ func (q *SelectQuery) GetAlias(tableName string) string {
 	return tableName
 }
 
-// From sets the primary table the query will get values from.
+// Table returns the table the query selects from, nil if From has not been called.
+func (q *SelectQuery) Table() Table {
+	return q.from
+}
+
+// Dialect sets the database dialect used to render this query, defaults to MySQL.
+func (q *SelectQuery) Dialect(dialect Dialect) *SelectQuery {
+	q.dialect = dialect
+	return q
+}
+
+// As returns this query as a derived table with the passed alias for use in the from or join of another query.
+func (q *SelectQuery) As(alias string) *DerivedTable {
+	return &DerivedTable{query: q, alias: alias}
+}
+
+// From sets the primary table the query will get values from, this can be a DerivedTable.
 func (q *SelectQuery) From(table Table) *SelectQuery {
 	q.from = table
 	return q
@@ -138,7 +156,13 @@ func (q *SelectQuery) GroupBy(expressions ...SelectExpression) *SelectQuery {
 	return q
 }
 
-func (q *SelectQuery) selectExpressionsSQL() string {
+// Having filters the groups of a GroupBy based on the passed condition, which can include aggregates.
+func (q *SelectQuery) Having(condition *ConditionExpression) *SelectQuery {
+	q.having.expression = condition
+	return q
+}
+
+func (q *SelectQuery) selectExpressionsSQL() (string, []interface{}) {
 	var prefix string
 	if q.distinct {
 		prefix = "SELECT DISTINCT"
@@ -146,15 +170,34 @@ func (q *SelectQuery) selectExpressionsSQL() string {
 		prefix = "SELECT"
 	}
 	expressions := make([]string, len(q.selectExps))
+	values := []interface{}{}
 	for i, exp := range q.selectExps {
 		expressions[i] = exp.SQL()
+		if raw, ok := exp.(*Expression); ok {
+			values = append(values, raw.Values()...)
+		}
 	}
-	return fmt.Sprintf("%s %s", prefix, strings.Join(expressions, ", "))
+	return fmt.Sprintf("%s %s", prefix, strings.Join(expressions, ", ")), values
 }
 
 // Validate that this query can be executed.
 func (q *SelectQuery) Validate() bool {
 	q.err = nil
+	missingTables, err := q.missingTables()
+	if nil != err {
+		q.err = err
+		return false
+	}
+	if len(missingTables) > 0 {
+		q.err = NewMissingTablesError(missingTables)
+		return false
+	}
+	return true
+}
+
+// missingTables returns the tables used in this query that are not part of the from or a join. When this query is
+// used as a subquery these tables must be provided by the enclosing query.
+func (q *SelectQuery) missingTables() ([]string, error) {
 	// gather up all the tables that must be present in the from or in a join
 	tablesRequired := make(map[string]bool)
 	// check the select
@@ -177,59 +220,87 @@ func (q *SelectQuery) Validate() bool {
 			tablesRequired[table] = true
 		}
 	}
+	// and the having
+	for _, table := range q.having.tables() {
+		tablesRequired[table] = true
+	}
 	// check that the from table is set
 	if nil == q.from {
-		q.err = NewValidationFromNotSetError()
-		return false
+		return nil, NewValidationFromNotSetError()
+	}
+	// subqueries must be valid on their own
+	subqueries := []*SelectQuery{}
+	if nil != q.where.expression {
+		subqueries = append(subqueries, q.where.expression.subqueries()...)
+	}
+	if nil != q.having.expression {
+		subqueries = append(subqueries, q.having.expression.subqueries()...)
+	}
+	if derived, ok := q.from.(*DerivedTable); ok {
+		subqueries = append(subqueries, derived.query)
+	}
+	for _, join := range q.joins {
+		if derived, ok := join.table.(*DerivedTable); ok {
+			subqueries = append(subqueries, derived.query)
+		}
+	}
+	for _, subquery := range subqueries {
+		if _, err := subquery.missingTables(); nil != err {
+			return nil, err
+		}
 	}
 	delete(tablesRequired, q.from.GetAlias())
 
 	for _, join := range q.joins {
 		delete(tablesRequired, join.table.GetAlias())
 		if nil != join.err {
-			q.err = join.err
-			return false
+			return nil, join.err
 		}
 	}
 
-	if len(tablesRequired) > 0 {
-		missingTables := make([]string, len(tablesRequired))
-		i := 0
-		for key := range tablesRequired {
-			missingTables[i] = key
-			i++
-		}
-		q.err = NewMissingTablesError(missingTables)
-		return false
+	missingTables := make([]string, 0, len(tablesRequired))
+	for key := range tablesRequired {
+		missingTables = append(missingTables, key)
 	}
-
-	return true
+	return missingTables, nil
 }
 
 // SQL statement corresponding to this query.
 func (q *SelectQuery) SQL(limit, offset uint) (string, []interface{}, error) {
+	return q.DialectSQL(q.dialect, limit, offset)
+}
+
+// DialectSQL is the SQL statement corresponding to this query rendered for the passed dialect, the dialect set on the
+// query is left unchanged.
+func (q *SelectQuery) DialectSQL(dialect Dialect, limit, offset uint) (string, []interface{}, error) {
 	if !q.Validate() {
 		return "", []interface{}{}, q.err
 	}
+	dialect = dialectOrDefault(dialect)
+	sql, values := q.render(dialect, limit, offset)
+	return bind(dialect, sql), values, q.err
+}
+
+// render this query without validation or binding so that it can be used as a subquery.
+func (q *SelectQuery) render(dialect Dialect, limit, offset uint) (string, []interface{}) {
 	// SELECT
-	lines := []string{q.selectExpressionsSQL()}
-	var values []interface{}
+	selectSQL, values := q.selectExpressionsSQL()
+	lines := []string{selectSQL}
 
 	// FROM
-	from := fmt.Sprintf("FROM `%s` AS `%s`",
-		q.from.GetName(),
-		q.from.GetAlias())
-	lines = append(lines, from)
+	from, fromValues := tableReferenceSQL(dialect, q.from)
+	lines = append(lines, "FROM "+from)
+	values = append(values, fromValues...)
 
 	// JOIN
 	for _, join := range q.joins {
-		joinSQL, joinValues := join.SQL()
+		joinSQL, joinValues := join.sql(dialect)
 		lines = append(lines, joinSQL)
 		values = append(values, joinValues...)
 	}
 
 	// WHERE
-	if where, whereValues, ok := q.where.sql(); ok {
+	if where, whereValues, ok := q.where.sql(dialect); ok {
 		lines = append(lines, "WHERE", where)
 		values = append(values, whereValues...)
 	}
@@ -238,12 +309,24 @@ func (q *SelectQuery) SQL(limit, offset uint) (string, []interface{}, error) {
 	if len(q.groupBy) > 0 {
 		groupByLines := []string{}
 		for _, tf := range q.groupBy {
+			if raw, ok := tf.(*Expression); ok {
+				rawSQL, rawValues := raw.operand(dialect)
+				groupByLines = append(groupByLines, rawSQL)
+				values = append(values, rawValues...)
+				continue
+			}
 			groupByLines = append(groupByLines, tf.SQL())
 		}
 		groupByStatement := "GROUP BY " + strings.Join(groupByLines, ", ")
 		lines = append(lines, groupByStatement)
 	}
 
+	// HAVING
+	if having, havingValues, ok := q.having.sql(dialect); ok {
+		lines = append(lines, "HAVING", having)
+		values = append(values, havingValues...)
+	}
+
 	// ORDER BY
 	if orderby, ok := q.orderBy.sql(); ok {
 		lines = append(lines, orderby)
@@ -253,5 +336,58 @@ func (q *SelectQuery) SQL(limit, offset uint) (string, []interface{}, error) {
 	if NoLimit != limit {
 		lines = append(lines, fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset))
 	}
-	return strings.Join(lines, q.Seperator), values, q.err
+	return strings.Join(lines, q.Seperator), values
+}
+
+// DerivedTable is a select query used as a table in the from or join of another query.
+type DerivedTable struct {
+	query *SelectQuery
+	alias string
+}
+
+// Field of this derived table with the passed name, which should match the name of a select expression.
+func (dt *DerivedTable) Field(name string) TableField {
+	return TableField{Name: name, Table: dt.alias}
+}
+
+// GetName of the derived table, which is it's alias.
+func (dt *DerivedTable) GetName() string {
+	return dt.alias
+}
+
+// GetAlias of the derived table for use in the query.
+func (dt *DerivedTable) GetAlias() string {
+	return dt.alias
+}
+
+// PrimaryKey of the derived table is the first select expression.
+func (dt *DerivedTable) PrimaryKey() TableField {
+	if len(dt.query.selectExps) == 0 {
+		return dt.AllColumns()
+	}
+	return dt.ReadColumns()[0]
+}
+
+// AllColumns of the derived table.
+func (dt *DerivedTable) AllColumns() TableField {
+	return dt.Field("*")
+}
+
+// ReadColumns of the derived table, one per select expression.
+func (dt *DerivedTable) ReadColumns() []TableField {
+	columns := make([]TableField, len(dt.query.selectExps))
+	for i, exp := range dt.query.selectExps {
+		columns[i] = dt.Field(exp.GetName())
+	}
+	return columns
+}
+
+// WriteColumns is always empty as a derived table cannot be written to.
+func (dt *DerivedTable) WriteColumns() []TableField {
+	return []TableField{}
+}
+
+// SortBy the first select expression.
+func (dt *DerivedTable) SortBy() (TableField, OrderDirection) {
+	return dt.PrimaryKey(), Ascending
 }
@@ -28,24 +28,55 @@ import (
 // Currently only supports single table, to change this the tableReference would have to be built out more.
 type UpdateQuery struct {
 	tableReference Table
-	assignments    []comparisonExpression
+	assignments    []assignment
 	where          *whereCondition
 	orderBy        *orderBy
+	dialect        Dialect
 	err            error
 }
 
+// assignment of a value or named parameter to a column
+type assignment struct {
+	field TableField
+	value expressionUnion
+	param bool
+}
+
+func (a assignment) sql(dialect Dialect) (string, []interface{}) {
+	column := assignmentColumn(dialect, a.field)
+	if a.param {
+		return fmt.Sprintf("%s = :%s", column, a.field.GetName()), []interface{}{}
+	}
+	value, values := a.value.sql(dialect)
+	return fmt.Sprintf("%s = %s", column, value), values
+}
+
+// assignmentColumn returns the column for use as the target of an assignment or insert
+func assignmentColumn(dialect Dialect, field TableField) string {
+	if dialect.QualifyAssignments() {
+		return field.SQL()
+	}
+	return fmt.Sprintf("`%s`", field.Name)
+}
+
 // GetAlias returns the alias for the passed tablename used in this query.
 func (q *UpdateQuery) GetAlias(tableName string) string {
 	// no aliasing in update
 	return tableName
 }
 
+// Dialect sets the database dialect used to render this query, defaults to MySQL.
+func (q *UpdateQuery) Dialect(dialect Dialect) *UpdateQuery {
+	q.dialect = dialect
+	return q
+}
+
 // Set adds a assignment to this update query.
 func (q *UpdateQuery) Set(field TableField, value interface{}) *UpdateQuery {
 	if field.Table != q.tableReference.GetName() {
 		q.err = errors.New("field table does not match table reference on update query")
 	} else {
-		q.assignments = append(q.assignments, binaryExpression{left: field, comparison: Equal, right: newUnion(value)})
+		q.assignments = append(q.assignments, assignment{field: field, value: newUnion(value)})
 	}
 	return q
 }
@@ -55,7 +86,7 @@ func (q *UpdateQuery) SetParam(field TableField) *UpdateQuery {
 	if field.Table != q.tableReference.GetName() {
 		q.err = errors.New("field table does not match table reference on update query")
 	} else {
-		q.assignments = append(q.assignments, parameterExpression{left: field, comparison: Equal})
+		q.assignments = append(q.assignments, assignment{field: field, param: true})
 	}
 	return q
 }
@@ -80,17 +111,18 @@ func (q *UpdateQuery) SQL(limit int) (string, []interface{}, error) {
 	if len(q.assignments) == 0 {
 		return "", nil, errors.New("no assignments in update query")
 	}
+	dialect := dialectOrDefault(q.dialect)
 	sql := []string{fmt.Sprintf("UPDATE `%s` SET ", q.tableReference.GetName())}
 	alines := []string{}
 	values := []interface{}{}
 	for _, assignment := range q.assignments {
-		s, v := assignment.SQL()
+		s, v := assignment.sql(dialect)
 		alines = append(alines, s)
 		values = append(values, v...)
 	}
 	sql = append(sql, strings.Join(alines, ", "))
 	// WHERE
-	if where, whereValues, ok := q.where.sql(); ok {
+	if where, whereValues, ok := q.where.sql(dialect); ok {
 		sql = append(sql, "WHERE", where)
 		values = append(values, whereValues...)
 	}
@@ -102,7 +134,7 @@ func (q *UpdateQuery) SQL(limit int) (string, []interface{}, error) {
 	if NoLimit != limit {
 		sql = append(sql, fmt.Sprintf("LIMIT %d", limit))
 	}
-	return strings.Join(sql, " "), values, q.err
+	return bind(dialect, strings.Join(sql, " ")), values, q.err
 }
 
 // ParameterizedSQL representation of this query.
@@ -113,17 +145,18 @@ func (q *UpdateQuery) ParameterizedSQL(limit int) (string, error) {
 	if len(q.assignments) == 0 {
 		return "", errors.New("no assignments in update query")
 	}
+	dialect := dialectOrDefault(q.dialect)
 	sql := []string{fmt.Sprintf("UPDATE `%s` SET ", q.tableReference.GetName())}
 	alines := []string{}
 	values := []interface{}{}
 	for _, assignment := range q.assignments {
-		s, v := assignment.SQL()
+		s, v := assignment.sql(dialect)
 		alines = append(alines, s)
 		values = append(values, v...)
 	}
 	sql = append(sql, strings.Join(alines, ", "))
 	// WHERE
-	if where, _, ok := q.where.sql(); ok {
+	if where, _, ok := q.where.sql(dialect); ok {
 		sql = append(sql, "WHERE", where)
 	}
 	// ORDER BY
@@ -134,5 +167,5 @@ func (q *UpdateQuery) ParameterizedSQL(limit int) (string, error) {
 	if NoLimit != limit {
 		sql = append(sql, fmt.Sprintf("LIMIT %d", limit))
 	}
-	return strings.Join(sql, " "), q.err
+	return bind(dialect, strings.Join(sql, " ")), q.err
 }
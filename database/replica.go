@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Kasita-Inc/gadget/log"
+)
+
+// ReplicaHealthInterval is how often the replicas passed to Initialize are checked
+var ReplicaHealthInterval = 5 * time.Second
+
+// ReplicaHealthTimeout is how long a replica has to respond to a health check
+var ReplicaHealthTimeout = time.Second
+
+// replica is a read only connection that is skipped while it is unhealthy
+type replica struct {
+	db      *sqlx.DB
+	healthy int32
+}
+
+func (r *replica) isHealthy() bool {
+	return 1 == atomic.LoadInt32(&r.healthy)
+}
+
+// replicaSet routes reads to its healthy replicas using round-robin
+type replicaSet struct {
+	replicas []*replica
+	next     uint64
+	stop     chan struct{}
+	stopOnce sync.Once
+	logger   log.Logger
+}
+
+// newReplicaSet opens the replica connections and checks them every interval until closed
+func newReplicaSet(dialect string, urls []string, interval time.Duration, logger log.Logger) *replicaSet {
+	rs := &replicaSet{
+		replicas: make([]*replica, 0, len(urls)),
+		stop:     make(chan struct{}),
+		logger:   logger,
+	}
+	for _, url := range urls {
+		// open does not connect, unreachable replicas are marked unhealthy by the check
+		conn, err := sqlx.Open(dialect, url)
+		if nil != err {
+			logger.Errorf("Could not open replica\n%v", err)
+			continue
+		}
+		rs.replicas = append(rs.replicas, &replica{db: conn})
+	}
+	rs.check()
+	go rs.monitor(interval)
+	return rs
+}
+
+// reader returns the next healthy replica or nil when there are none
+func (rs *replicaSet) reader() *sqlx.DB {
+	count := uint64(len(rs.replicas))
+	for i := uint64(0); i < count; i++ {
+		r := rs.replicas[(atomic.AddUint64(&rs.next, 1)-1)%count]
+		if r.isHealthy() {
+			return r.db
+		}
+	}
+	return nil
+}
+
+// check pings each replica and updates its health
+func (rs *replicaSet) check() {
+	for i, r := range rs.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), ReplicaHealthTimeout)
+		err := r.db.PingContext(ctx)
+		cancel()
+		var healthy int32
+		if nil == err {
+			healthy = 1
+		}
+		if previous := atomic.SwapInt32(&r.healthy, healthy); previous != healthy {
+			if nil != err {
+				rs.logger.Warnf("replica %d is unhealthy: %s", i, err)
+			} else {
+				rs.logger.Infof("replica %d is healthy", i)
+			}
+		}
+	}
+}
+
+func (rs *replicaSet) monitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-ticker.C:
+			rs.check()
+		}
+	}
+}
+
+// close stops the health checks and closes the replica connections
+func (rs *replicaSet) close() {
+	rs.stopOnce.Do(func() {
+		close(rs.stop)
+		for _, r := range rs.replicas {
+			r.db.Close()
+		}
+	})
+}
+
+// Primary returns a Database whose reads use the primary even when replicas are configured, use it for reads that
+// must observe a preceding write
+func (db *Database) Primary() *Database {
+	primary := *db
+	primary.primary = true
+	return &primary
+}
+
+// reader returns the connection used for reads outside of a transaction
+func (db *Database) reader() *sqlx.DB {
+	if db.primary || nil == db.replicas {
+		return db.DB
+	}
+	if conn := db.replicas.reader(); nil != conn {
+		return conn
+	}
+	return db.DB
+}
+
+// Close the connections to the primary and any replicas
+func (db *Database) Close() error {
+	if nil != db.replicas {
+		db.replicas.close()
+	}
+	return db.DB.Close()
+}
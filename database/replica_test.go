@@ -0,0 +1,72 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/generator"
+	"github.com/Kasita-Inc/gadget/log"
+)
+
+// unreachable is a replica url that refuses connections
+const unreachable = "user:pass@tcp(127.0.0.1:1)/test?timeout=100ms"
+
+func TestReplicaSetRoundRobin(t *testing.T) {
+	assert := assert.New(t)
+	rs := newReplicaSet("mysql", []string{unreachable, unreachable, unreachable}, time.Hour, log.NewStackLogger())
+	defer rs.close()
+	assert.Len(rs.replicas, 3)
+	for _, r := range rs.replicas {
+		assert.False(r.isHealthy())
+	}
+	assert.Nil(rs.reader())
+
+	rs.replicas[0].healthy = 1
+	rs.replicas[2].healthy = 1
+	assert.Equal(rs.replicas[0].db, rs.reader())
+	assert.Equal(rs.replicas[2].db, rs.reader())
+	assert.Equal(rs.replicas[0].db, rs.reader())
+
+	// the next health check marks the unreachable replicas as unhealthy again
+	rs.check()
+	assert.Nil(rs.reader())
+}
+
+func TestDatabaseReader(t *testing.T) {
+	assert := assert.New(t)
+	primary := &sqlx.DB{}
+	db := &Database{DB: primary}
+	assert.Equal(primary, db.reader())
+
+	db.replicas = newReplicaSet("mysql", []string{unreachable}, time.Hour, log.NewStackLogger())
+	defer db.replicas.close()
+	assert.Equal(primary, db.reader())
+
+	db.replicas.replicas[0].healthy = 1
+	assert.Equal(db.replicas.replicas[0].db, db.reader())
+	assert.Equal(primary, db.Primary().reader())
+}
+
+func TestReplicaReads(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+	db := Initialize(spec, spec.DatabaseConnection(), unreachable)
+	defer db.Close()
+	assert.True(db.replicas.replicas[0].isHealthy())
+	assert.False(db.replicas.replicas[1].isHealthy())
+
+	expected := &TestRecord{Name: generator.Name()}
+	assert.NoError(db.Create(expected))
+	actual := &TestRecord{}
+	assert.NoError(db.Primary().Read(actual, expected.PrimaryKey()))
+	assert.Equal(expected, actual)
+	actual = &TestRecord{}
+	assert.NoError(db.Read(actual, expected.PrimaryKey()))
+	assert.Equal(expected, actual)
+	records := []TestRecord{}
+	assert.NoError(db.ListWhere(&TestRecord{}, &records, TestMeta.Name.Equal(expected.Name)))
+	assert.Len(records, 1)
+}
@@ -0,0 +1,350 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/Kasita-Inc/gadget/database/qb"
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+const (
+	columnsQuery = "SELECT COLUMN_NAME, DATA_TYPE FROM information_schema.COLUMNS " +
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION"
+	indexesQuery = "SELECT INDEX_NAME, COLUMN_NAME FROM information_schema.STATISTICS " +
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY INDEX_NAME, SEQ_IN_INDEX"
+	// schemaTable is the table reported to the query hooks for the schema queries
+	schemaTable = "information_schema"
+)
+
+// type categories used to compare the type of a field to the type of a column
+const (
+	categoryInt    = "int"
+	categoryFloat  = "float"
+	categoryBool   = "bool"
+	categoryString = "string"
+	categoryBytes  = "bytes"
+	categoryTime   = "time"
+)
+
+var (
+	schemaRecords = []Record{}
+	schemaMutex   sync.Mutex
+)
+
+// RegisterSchema registers Records whose tables are checked by CheckSchema
+func RegisterSchema(records ...Record) {
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+	schemaRecords = append(schemaRecords, records...)
+}
+
+// SchemaDiff describes how the schema of the database differs from the qb.Table definitions of the checked Records
+type SchemaDiff struct {
+	Tables []TableDiff
+}
+
+// Empty is true when the schema matches the qb.Table definitions
+func (diff *SchemaDiff) Empty() bool {
+	return len(diff.Tables) == 0
+}
+
+// String lists the differences one per line
+func (diff *SchemaDiff) String() string {
+	lines := []string{}
+	for _, table := range diff.Tables {
+		if table.Missing {
+			lines = append(lines, fmt.Sprintf("%s: table does not exist", table.Table))
+			continue
+		}
+		for _, column := range table.MissingColumns {
+			lines = append(lines, fmt.Sprintf("%s.%s: column does not exist", table.Table, column))
+		}
+		for _, column := range table.ExtraColumns {
+			lines = append(lines, fmt.Sprintf("%s.%s: column is not defined", table.Table, column))
+		}
+		for _, mismatch := range table.TypeMismatches {
+			lines = append(lines, fmt.Sprintf("%s.%s: column type %s does not match field %s of type %s",
+				table.Table, mismatch.Column, mismatch.DataType, mismatch.Field, mismatch.FieldType))
+		}
+		for _, index := range table.MissingIndexes {
+			lines = append(lines, fmt.Sprintf("%s(%s): index does not exist", table.Table, strings.Join(index, ", ")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TableDiff describes how a table in the database differs from its qb.Table definition
+type TableDiff struct {
+	Table string
+	// Missing is true when the table does not exist, no other differences are reported
+	Missing bool
+	// MissingColumns are defined by the qb.Table but do not exist in the table
+	MissingColumns []string
+	// ExtraColumns exist in the table but are not defined by the qb.Table
+	ExtraColumns []string
+	// TypeMismatches are columns whose type cannot be scanned into the field of the Record
+	TypeMismatches []ColumnMismatch
+	// MissingIndexes are the columns of the indexes that do not exist in the table
+	MissingIndexes [][]string
+}
+
+// empty is true when there are no differences
+func (diff *TableDiff) empty() bool {
+	return !diff.Missing && len(diff.MissingColumns) == 0 && len(diff.ExtraColumns) == 0 &&
+		len(diff.TypeMismatches) == 0 && len(diff.MissingIndexes) == 0
+}
+
+// ColumnMismatch is a column whose type is not compatible with the field of the Record it is read into
+type ColumnMismatch struct {
+	Column    string
+	DataType  string
+	Field     string
+	FieldType string
+}
+
+type schemaColumn struct {
+	Name     string `db:"COLUMN_NAME"`
+	DataType string `db:"DATA_TYPE"`
+}
+
+type schemaIndex struct {
+	Name   string `db:"INDEX_NAME"`
+	Column string `db:"COLUMN_NAME"`
+}
+
+// CheckSchema compares the tables of the passed Records, or of the Records passed to RegisterSchema when there are
+// none, to the schema of the database. It reports missing and extra columns, columns whose type is not compatible with
+// the fields of the Record and indexes declared by a qb.IndexedTable that do not exist.
+func (db *Database) CheckSchema(records ...Record) (*SchemaDiff, errors.TracerError) {
+	if nil != db.Dialect && qb.MySQL != db.Dialect {
+		return nil, NewValidationError("schema checks are only supported for mysql")
+	}
+	if len(records) == 0 {
+		schemaMutex.Lock()
+		records = append(records, schemaRecords...)
+		schemaMutex.Unlock()
+	}
+	diff := &SchemaDiff{Tables: []TableDiff{}}
+	for _, record := range records {
+		tableDiff, err := db.checkTable(record)
+		if nil != err {
+			return nil, err
+		}
+		if !tableDiff.empty() {
+			diff.Tables = append(diff.Tables, *tableDiff)
+		}
+	}
+	return diff, nil
+}
+
+// ValidateSchema returns a SchemaDriftError when CheckSchema finds any differences
+func (db *Database) ValidateSchema(records ...Record) errors.TracerError {
+	diff, err := db.CheckSchema(records...)
+	if nil != err {
+		return err
+	}
+	if !diff.Empty() {
+		return NewSchemaDriftError(diff)
+	}
+	return nil
+}
+
+func (db *Database) checkTable(record Record) (*TableDiff, errors.TracerError) {
+	meta := record.Meta()
+	diff := &TableDiff{Table: meta.GetName()}
+	columns := []schemaColumn{}
+	if err := db.selectContext(context.Background(), db.DB, schemaTable, &columns, columnsQuery, meta.GetName()); nil != err {
+		return nil, TranslateError(err, Select, columnsQuery, db.Logger)
+	}
+	if len(columns) == 0 {
+		diff.Missing = true
+		return diff, nil
+	}
+
+	expected := appendIfMissing(meta.ReadColumns(), meta.PrimaryKey())
+	for _, field := range meta.WriteColumns() {
+		expected = appendIfMissing(expected, field)
+	}
+	actual := make(map[string]schemaColumn, len(columns))
+	for _, column := range columns {
+		actual[column.Name] = column
+	}
+	defined := make(map[string]bool, len(expected))
+	fields := db.Mapper.TypeMap(reflect.TypeOf(record))
+	for _, field := range expected {
+		name := field.GetName()
+		defined[name] = true
+		column, ok := actual[name]
+		if !ok {
+			diff.MissingColumns = append(diff.MissingColumns, name)
+			continue
+		}
+		info := fields.GetByPath(name)
+		if nil == info {
+			continue
+		}
+		if !compatibleType(info.Field.Type, column.DataType) {
+			diff.TypeMismatches = append(diff.TypeMismatches, ColumnMismatch{
+				Column:    name,
+				DataType:  column.DataType,
+				Field:     info.Field.Name,
+				FieldType: info.Field.Type.String(),
+			})
+		}
+	}
+	for _, column := range columns {
+		if !defined[column.Name] {
+			diff.ExtraColumns = append(diff.ExtraColumns, column.Name)
+		}
+	}
+
+	indexes := []schemaIndex{}
+	if err := db.selectContext(context.Background(), db.DB, schemaTable, &indexes, indexesQuery, meta.GetName()); nil != err {
+		return nil, TranslateError(err, Select, indexesQuery, db.Logger)
+	}
+	diff.MissingIndexes = missingIndexes(expectedIndexes(meta), indexes)
+	return diff, nil
+}
+
+// expectedIndexes returns the columns of the primary key and of any indexes declared by a qb.IndexedTable
+func expectedIndexes(meta qb.Table) [][]string {
+	expected := [][]string{{meta.PrimaryKey().GetName()}}
+	if indexed, ok := meta.(qb.IndexedTable); ok {
+		for _, index := range indexed.Indexes() {
+			columns := make([]string, len(index))
+			for i, field := range index {
+				columns[i] = field.GetName()
+			}
+			expected = append(expected, columns)
+		}
+	}
+	return expected
+}
+
+// missingIndexes returns the expected indexes that are not a leading prefix of an existing index, the schema indexes
+// must be ordered by index name and position in the index
+func missingIndexes(expected [][]string, indexes []schemaIndex) [][]string {
+	existing := map[string][]string{}
+	for _, index := range indexes {
+		existing[index.Name] = append(existing[index.Name], index.Column)
+	}
+	names := make([]string, 0, len(existing))
+	for name := range existing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var missing [][]string
+	for _, columns := range expected {
+		found := false
+		for _, name := range names {
+			if isPrefix(columns, existing[name]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, columns)
+		}
+	}
+	return missing
+}
+
+func isPrefix(prefix []string, columns []string) bool {
+	if len(prefix) > len(columns) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != columns[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	nullTimeType   = reflect.TypeOf(mysql.NullTime{})
+	nullStringType = reflect.TypeOf(sql.NullString{})
+	nullInt64Type  = reflect.TypeOf(sql.NullInt64{})
+	nullFloatType  = reflect.TypeOf(sql.NullFloat64{})
+	nullBoolType   = reflect.TypeOf(sql.NullBool{})
+)
+
+// fieldCategory returns the type category of a field, or "" when the type is not known such as a custom sql.Scanner
+func fieldCategory(fieldType reflect.Type) string {
+	for reflect.Ptr == fieldType.Kind() {
+		fieldType = fieldType.Elem()
+	}
+	switch fieldType {
+	case timeType, nullTimeType:
+		return categoryTime
+	case nullStringType:
+		return categoryString
+	case nullInt64Type:
+		return categoryInt
+	case nullFloatType:
+		return categoryFloat
+	case nullBoolType:
+		return categoryBool
+	}
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return categoryInt
+	case reflect.Float32, reflect.Float64:
+		return categoryFloat
+	case reflect.Bool:
+		return categoryBool
+	case reflect.String:
+		return categoryString
+	case reflect.Slice:
+		if reflect.Uint8 == fieldType.Elem().Kind() {
+			return categoryBytes
+		}
+	}
+	return ""
+}
+
+// columnCategory returns the type category of a mysql data type, or "" when the type is not known
+func columnCategory(dataType string) string {
+	switch strings.ToLower(dataType) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "bit", "year":
+		return categoryInt
+	case "decimal", "numeric", "float", "double", "real":
+		return categoryFloat
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set", "json":
+		return categoryString
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return categoryBytes
+	case "date", "datetime", "timestamp":
+		return categoryTime
+	}
+	return ""
+}
+
+// compatibleType is true when a column of the data type can be scanned into a field of the type
+func compatibleType(fieldType reflect.Type, dataType string) bool {
+	field := fieldCategory(fieldType)
+	column := columnCategory(dataType)
+	if "" == field || "" == column || field == column {
+		return true
+	}
+	switch field {
+	case categoryBool, categoryFloat:
+		return categoryInt == column
+	case categoryString, categoryBytes:
+		// every value can be scanned into a string or []byte
+		return true
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/database/qb"
+)
+
+type driftRecord struct {
+	TestRecord
+	Missing string `db:"missing"`
+}
+
+type driftMeta struct {
+	testMeta
+}
+
+func (m *driftMeta) ReadColumns() []qb.TableField {
+	return append(m.testMeta.ReadColumns(), qb.TableField{Name: "missing", Table: m.GetName()})
+}
+
+func (m *driftMeta) Indexes() [][]qb.TableField {
+	return [][]qb.TableField{{m.Name, m.Place}}
+}
+
+func (record *driftRecord) Meta() qb.Table {
+	return &driftMeta{testMeta: *TestMeta}
+}
+
+func TestCheckSchema(t *testing.T) {
+	skipUnlessMySQL(t)
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	diff, err := spec.DB.CheckSchema(&TestRecord{}, &TestVersionedRecord{}, &TestArchiveRecord{})
+	assert.NoError(err)
+	assert.True(diff.Empty(), diff.String())
+	assert.NoError(spec.DB.ValidateSchema(&TestRecord{}))
+
+	diff, err = spec.DB.CheckSchema(&driftRecord{})
+	assert.NoError(err)
+	if assert.Len(diff.Tables, 1) {
+		assert.Equal("test_record", diff.Tables[0].Table)
+		assert.Equal([]string{"missing"}, diff.Tables[0].MissingColumns)
+		assert.Equal([][]string{{"name", "place"}}, diff.Tables[0].MissingIndexes)
+	}
+	assert.IsType(&SchemaDriftError{}, spec.DB.ValidateSchema(&driftRecord{}))
+}
+
+func TestMissingIndexes(t *testing.T) {
+	assert := assert.New(t)
+	indexes := []schemaIndex{
+		{Name: "PRIMARY", Column: "id"},
+		{Name: "name_place", Column: "name"},
+		{Name: "name_place", Column: "place"},
+	}
+	assert.Empty(missingIndexes([][]string{{"id"}, {"name"}, {"name", "place"}}, indexes))
+	assert.Equal([][]string{{"place"}, {"place", "name"}},
+		missingIndexes([][]string{{"id"}, {"place"}, {"place", "name"}}, indexes))
+}
+
+func TestCompatibleType(t *testing.T) {
+	assert := assert.New(t)
+	var pointer *int
+	tests := []struct {
+		value    interface{}
+		dataType string
+		expected bool
+	}{
+		{value: 1, dataType: "int", expected: true},
+		{value: pointer, dataType: "bigint", expected: true},
+		{value: true, dataType: "tinyint", expected: true},
+		{value: 1.5, dataType: "decimal", expected: true},
+		{value: 1.5, dataType: "int", expected: true},
+		{value: "", dataType: "datetime", expected: true},
+		{value: []byte{}, dataType: "blob", expected: true},
+		{value: sql.NullString{}, dataType: "varchar", expected: true},
+		{value: mysql.NullTime{}, dataType: "timestamp", expected: true},
+		{value: time.Time{}, dataType: "DATETIME", expected: true},
+		{value: struct{}{}, dataType: "varchar", expected: true},
+		{value: 1, dataType: "varchar", expected: false},
+		{value: sql.NullInt64{}, dataType: "datetime", expected: false},
+		{value: time.Time{}, dataType: "int", expected: false},
+		{value: true, dataType: "varchar", expected: false},
+	}
+	for _, test := range tests {
+		assert.Equal(test.expected, compatibleType(reflect.TypeOf(test.value), test.dataType),
+			"%T %s", test.value, test.dataType)
+	}
+}
+
+func TestSchemaDiffString(t *testing.T) {
+	assert := assert.New(t)
+	diff := &SchemaDiff{Tables: []TableDiff{
+		{Table: "gone", Missing: true},
+		{
+			Table:          "drift",
+			MissingColumns: []string{"a"},
+			ExtraColumns:   []string{"b"},
+			TypeMismatches: []ColumnMismatch{{Column: "c", DataType: "int", Field: "C", FieldType: "time.Time"}},
+			MissingIndexes: [][]string{{"d", "e"}},
+		},
+	}}
+	assert.False(diff.Empty())
+	assert.Equal("gone: table does not exist\n"+
+		"drift.a: column does not exist\n"+
+		"drift.b: column is not defined\n"+
+		"drift.c: column type int does not match field C of type time.Time\n"+
+		"drift(d, e): index does not exist", diff.String())
+}
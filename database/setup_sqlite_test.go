@@ -0,0 +1,7 @@
+// +build sqlite
+
+package database
+
+import (
+	_ "github.com/mattn/go-sqlite3" // imported for side effect as driver for the sqlite3 specification
+)
@@ -4,9 +4,11 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/mattes/migrate"
 
 	"github.com/Kasita-Inc/gadget/database/qb"
@@ -15,8 +17,12 @@ import (
 	"github.com/Kasita-Inc/gadget/log"
 )
 
+// sqliteTestFile is the database used when MOD_TEST_DATABASE_TYPE is sqlite3, the tests must be built with the sqlite
+// tag to register the driver
+var sqliteTestFile = filepath.Join(os.TempDir(), fmt.Sprintf("gadget_test_%d.db", os.Getpid()))
+
 type specification struct {
-	DatabaseType string
+	DatabaseType string `env:"MOD_TEST_DATABASE_TYPE,optional"`
 	DatabaseURL  string `env:"MOD_TEST_DATABASE_URL"`
 	DB           *Database
 }
@@ -37,11 +43,20 @@ func (spec specification) DatabaseDialectURL() string {
 }
 
 func newSpecification() *specification {
+	config := newConfig()
+	config.DB = Initialize(config)
+	return config
+}
+
+// newConfig returns the specification of the database under test without connecting to it
+func newConfig() *specification {
 	config := &specification{
 		DatabaseType: "mysql",
 	}
 	environment.Process(config)
-	config.DB = Initialize(config)
+	if qb.SQLite == qb.DialectFor(config.DatabaseType) {
+		config.DatabaseURL = fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL", sqliteTestFile)
+	}
 	return config
 }
 
@@ -93,6 +108,12 @@ func (p *testMeta) WriteColumns() []qb.TableField {
 	}
 }
 
+func (p *testMeta) Relations() []qb.Relation {
+	return []qb.Relation{
+		qb.NewHasMany("Items", p.ID, TestItemMeta, TestItemMeta.RecordID),
+	}
+}
+
 func (p *testMeta) Alias(alias string) *testMeta {
 	return &testMeta{
 		alias: alias,
@@ -115,6 +136,7 @@ type TestRecord struct {
 	CreatedOn time.Time      `db:"created_on,read_only"`
 	UpdatedOn time.Time      `db:"updated_on,read_only"`
 	Skip      string
+	Items     []TestItemRecord `db:"-"`
 }
 
 func (record *TestRecord) Initialize() {
@@ -274,6 +296,238 @@ func NewTestDuper() *TestDuper {
 	}
 }
 
+// testVersionedMeta defines a table with a version column
+type testVersionedMeta struct {
+	alias         string
+	ID            qb.TableField
+	Name          qb.TableField
+	VersionColumn qb.TableField
+}
+
+func (p *testVersionedMeta) GetName() string {
+	return "test_versioned"
+}
+
+func (p *testVersionedMeta) GetAlias() string {
+	return p.alias
+}
+
+func (p *testVersionedMeta) PrimaryKey() qb.TableField {
+	return p.ID
+}
+
+func (p *testVersionedMeta) AllColumns() qb.TableField {
+	return qb.TableField{Table: p.GetName(), Name: "*"}
+}
+
+func (p *testVersionedMeta) SortBy() (qb.TableField, qb.OrderDirection) {
+	return p.ID, qb.Ascending
+}
+
+func (p *testVersionedMeta) ReadColumns() []qb.TableField {
+	return []qb.TableField{
+		p.ID,
+		p.Name,
+		p.VersionColumn,
+	}
+}
+
+func (p *testVersionedMeta) WriteColumns() []qb.TableField {
+	return []qb.TableField{
+		p.Name,
+	}
+}
+
+func (p *testVersionedMeta) Version() qb.TableField {
+	return p.VersionColumn
+}
+
+func (p *testVersionedMeta) Alias(alias string) *testVersionedMeta {
+	return &testVersionedMeta{
+		alias:         alias,
+		ID:            qb.TableField{Name: "id", Table: alias},
+		Name:          qb.TableField{Name: "name", Table: alias},
+		VersionColumn: qb.TableField{Name: "version", Table: alias},
+	}
+}
+
+var TestVersionedMeta = (&testVersionedMeta{}).Alias("test_versioned")
+
+type TestVersionedRecord struct {
+	DefaultRecord
+	ID      string `db:"id"`
+	Name    string `db:"name"`
+	Version int    `db:"version,read_only"`
+}
+
+func (record *TestVersionedRecord) Initialize() {
+	record.ID = generator.ID("ver")
+}
+
+func (record *TestVersionedRecord) PrimaryKey() PrimaryKeyValue {
+	return NewPrimaryKey(record.ID)
+}
+
+func (record *TestVersionedRecord) Meta() qb.Table {
+	return TestVersionedMeta
+}
+
+// testArchiveMeta defines a table with soft deletes
+type testArchiveMeta struct {
+	alias     string
+	ID        qb.TableField
+	Name      qb.TableField
+	DeletedOn qb.TableField
+}
+
+func (p *testArchiveMeta) GetName() string {
+	return "test_archive"
+}
+
+func (p *testArchiveMeta) GetAlias() string {
+	return p.alias
+}
+
+func (p *testArchiveMeta) PrimaryKey() qb.TableField {
+	return p.ID
+}
+
+func (p *testArchiveMeta) AllColumns() qb.TableField {
+	return qb.TableField{Table: p.GetName(), Name: "*"}
+}
+
+func (p *testArchiveMeta) SortBy() (qb.TableField, qb.OrderDirection) {
+	return p.ID, qb.Ascending
+}
+
+func (p *testArchiveMeta) ReadColumns() []qb.TableField {
+	return []qb.TableField{
+		p.ID,
+		p.Name,
+		p.DeletedOn,
+	}
+}
+
+func (p *testArchiveMeta) WriteColumns() []qb.TableField {
+	return []qb.TableField{
+		p.Name,
+	}
+}
+
+func (p *testArchiveMeta) SoftDelete() qb.TableField {
+	return p.DeletedOn
+}
+
+func (p *testArchiveMeta) Alias(alias string) *testArchiveMeta {
+	return &testArchiveMeta{
+		alias:     alias,
+		ID:        qb.TableField{Name: "id", Table: alias},
+		Name:      qb.TableField{Name: "name", Table: alias},
+		DeletedOn: qb.TableField{Name: "deleted_on", Table: alias},
+	}
+}
+
+var TestArchiveMeta = (&testArchiveMeta{}).Alias("test_archive")
+
+type TestArchiveRecord struct {
+	DefaultRecord
+	ID        string         `db:"id"`
+	Name      string         `db:"name"`
+	DeletedOn mysql.NullTime `db:"deleted_on,read_only"`
+}
+
+func (record *TestArchiveRecord) Initialize() {
+	record.ID = generator.ID("arc")
+}
+
+func (record *TestArchiveRecord) PrimaryKey() PrimaryKeyValue {
+	return NewPrimaryKey(record.ID)
+}
+
+func (record *TestArchiveRecord) Meta() qb.Table {
+	return TestArchiveMeta
+}
+
+// testItemMeta defines a table that belongs to test_record
+type testItemMeta struct {
+	alias    string
+	ID       qb.TableField
+	RecordID qb.TableField
+	Name     qb.TableField
+}
+
+func (p *testItemMeta) GetName() string {
+	return "test_item"
+}
+
+func (p *testItemMeta) GetAlias() string {
+	return p.alias
+}
+
+func (p *testItemMeta) PrimaryKey() qb.TableField {
+	return p.ID
+}
+
+func (p *testItemMeta) AllColumns() qb.TableField {
+	return qb.TableField{Table: p.GetName(), Name: "*"}
+}
+
+func (p *testItemMeta) SortBy() (qb.TableField, qb.OrderDirection) {
+	return p.Name, qb.Ascending
+}
+
+func (p *testItemMeta) ReadColumns() []qb.TableField {
+	return []qb.TableField{
+		p.ID,
+		p.RecordID,
+		p.Name,
+	}
+}
+
+func (p *testItemMeta) WriteColumns() []qb.TableField {
+	return []qb.TableField{
+		p.RecordID,
+		p.Name,
+	}
+}
+
+func (p *testItemMeta) Relations() []qb.Relation {
+	return []qb.Relation{
+		qb.NewBelongsTo("Record", p.RecordID, TestMeta, TestMeta.ID),
+	}
+}
+
+func (p *testItemMeta) Alias(alias string) *testItemMeta {
+	return &testItemMeta{
+		alias:    alias,
+		ID:       qb.TableField{Name: "id", Table: alias},
+		RecordID: qb.TableField{Name: "record_id", Table: alias},
+		Name:     qb.TableField{Name: "name", Table: alias},
+	}
+}
+
+var TestItemMeta = (&testItemMeta{}).Alias("test_item")
+
+type TestItemRecord struct {
+	DefaultRecord
+	ID       string      `db:"id"`
+	RecordID string      `db:"record_id"`
+	Name     string      `db:"name"`
+	Record   *TestRecord `db:"-"`
+}
+
+func (record *TestItemRecord) Initialize() {
+	record.ID = generator.ID("itm")
+}
+
+func (record *TestItemRecord) PrimaryKey() PrimaryKeyValue {
+	return NewPrimaryKey(record.ID)
+}
+
+func (record *TestItemRecord) Meta() qb.Table {
+	return TestItemMeta
+}
+
 func rollback(migrations map[string]string, dbURL string) {
 	sqlFilesPath, _ := generateSQLFiles(migrations)
 	m, err := migrate.New(sqlFilesPath, dbURL)
@@ -305,10 +559,31 @@ func TestMain(m *testing.M) {
 		CREATE TABLE IF NOT EXISTS test_duper (
 			id varchar(128) primary key
 		);
+		CREATE TABLE IF NOT EXISTS test_versioned (
+			id varchar(128) primary key,
+			name varchar(128) not null,
+			version int not null default 1
+		);
+		CREATE TABLE IF NOT EXISTS test_archive (
+			id varchar(128) primary key,
+			name varchar(128) not null,
+			deleted_on TIMESTAMP NULL
+		);
+		CREATE TABLE IF NOT EXISTS test_item (
+			id varchar(128) primary key,
+			record_id varchar(128) not null,
+			name varchar(128) not null
+		);
 `
 	migrations["0001_foo.down.sql"] = `DROP TABLE IF EXISTS test_record;
 	DROP TABLE IF EXISTS test_duper;
+	DROP TABLE IF EXISTS test_versioned;
+	DROP TABLE IF EXISTS test_archive;
+	DROP TABLE IF EXISTS test_item;
 `
+	if qb.SQLite == config.DB.Dialect {
+		os.Exit(runSQLite(m, config))
+	}
 	Migrate(migrations, config.DatabaseDialectURL())
 
 	res := m.Run()
@@ -316,3 +591,57 @@ func TestMain(m *testing.M) {
 
 	os.Exit(res)
 }
+
+// sqliteSchema is the schema of the migrations for SQLite, which the migrate drivers of the tests do not support.
+// Timestamps default to the format the driver writes time.Time values in so that they compare as text.
+var sqliteSchema = `CREATE TABLE IF NOT EXISTS test_record (
+		id varchar(128) primary key,
+		name varchar(128) not null unique,
+		place varchar(128) null,
+		created_on TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S+00:00', 'now')),
+		updated_on TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S+00:00', 'now'))
+	);
+	CREATE TRIGGER IF NOT EXISTS test_record_updated_on AFTER UPDATE ON test_record
+	WHEN NEW.updated_on = OLD.updated_on AND (NEW.name IS NOT OLD.name OR NEW.place IS NOT OLD.place) BEGIN
+		UPDATE test_record SET updated_on = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now') WHERE id = NEW.id;
+	END;
+	CREATE TABLE IF NOT EXISTS test_duper (
+		id varchar(128) primary key
+	);
+	CREATE TABLE IF NOT EXISTS test_versioned (
+		id varchar(128) primary key,
+		name varchar(128) not null,
+		version int not null default 1
+	);
+	CREATE TABLE IF NOT EXISTS test_archive (
+		id varchar(128) primary key,
+		name varchar(128) not null,
+		deleted_on TIMESTAMP NULL
+	);
+	CREATE TABLE IF NOT EXISTS test_item (
+		id varchar(128) primary key,
+		record_id varchar(128) not null,
+		name varchar(128) not null
+	);
+`
+
+// runSQLite runs the tests against a SQLite database file that is removed afterwards
+func runSQLite(m *testing.M, config *specification) int {
+	defer func() {
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			os.Remove(sqliteTestFile + suffix)
+		}
+	}()
+	if _, err := config.DB.Exec(sqliteSchema); nil != err {
+		log.Error(err)
+		return 1
+	}
+	return m.Run()
+}
+
+// skipUnlessMySQL skips tests of behavior that only MySQL has
+func skipUnlessMySQL(t *testing.T) {
+	if dialect := qb.DialectFor(newConfig().DatabaseType); qb.MySQL != dialect {
+		t.Skipf("requires mysql, testing against %s", dialect.Name())
+	}
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Kasita-Inc/gadget/database/qb"
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+// WithDeleted returns a Database whose reads include the soft deleted rows of a qb.SoftDeleteTable
+func (db *Database) WithDeleted() *Database {
+	withDeleted := *db
+	withDeleted.withDeleted = true
+	return &withDeleted
+}
+
+// notDeleted adds the exclusion of soft deleted rows to the condition when the table is a qb.SoftDeleteTable
+func (db *Database) notDeleted(meta qb.Table, condition *qb.ConditionExpression) *qb.ConditionExpression {
+	softDelete, ok := meta.(qb.SoftDeleteTable)
+	if !ok || db.withDeleted {
+		return condition
+	}
+	// the caller's condition is composed rather than modified so it can be reused
+	return qb.AllOf(condition, softDelete.SoftDelete().IsNull())
+}
+
+// softDeleteWhereTx marks the rows matching the condition as deleted
+func (db *Database) softDeleteWhereTx(ctx context.Context, meta qb.SoftDeleteTable, tx *sqlx.Tx,
+	condition *qb.ConditionExpression) errors.TracerError {
+	if nil == condition {
+		return NewValidationError("delete requires a where clause")
+	}
+	column := meta.SoftDelete()
+	stmt, values, err := qb.Update(meta).
+		Set(column, qb.SQLNow).
+		Where(qb.AllOf(condition, column.IsNull())).
+		Dialect(db.Dialect).
+		SQL(qb.NoLimit)
+	if nil != err {
+		return errors.Wrap(err)
+	}
+	if _, err = db.execContext(ctx, tx, meta.GetName(), Update, stmt, values...); nil != err {
+		return TranslateError(err, Update, stmt, db.Logger)
+	}
+	return nil
+}
+
+// HardDelete removes a row from the database even if the Record's table is a qb.SoftDeleteTable
+func (db *Database) HardDelete(obj Record) errors.TracerError {
+	return db.HardDeleteContext(context.Background(), obj)
+}
+
+// HardDeleteContext removes a row from the database even if the Record's table is a qb.SoftDeleteTable, the delete is
+// cancelled with the context
+func (db *Database) HardDeleteContext(ctx context.Context, obj Record) errors.TracerError {
+	return db.InTxContext(ctx, func(tx *sqlx.Tx) error {
+		return db.HardDeleteTxContext(ctx, obj, tx)
+	})
+}
+
+// HardDeleteTx removes a row from the database using a transaction even if the Record's table is a
+// qb.SoftDeleteTable
+func (db *Database) HardDeleteTx(obj Record, tx *sqlx.Tx) errors.TracerError {
+	return db.HardDeleteTxContext(context.Background(), obj, tx)
+}
+
+// HardDeleteTxContext removes a row from the database using a transaction even if the Record's table is a
+// qb.SoftDeleteTable, the BeforeDeleter interface of the Record is called within the transaction
+func (db *Database) HardDeleteTxContext(ctx context.Context, obj Record, tx *sqlx.Tx) errors.TracerError {
+	if err := beforeDelete(obj, tx); nil != err {
+		return err
+	}
+	where := obj.Meta().PrimaryKey().Equal(obj.PrimaryKey().Value())
+	return db.HardDeleteWhereTxContext(ctx, obj, tx, where)
+}
+
+// HardDeleteWhere removes row(s) from the database based on a supplied where clause even if the Record's table is a
+// qb.SoftDeleteTable
+func (db *Database) HardDeleteWhere(obj Record, where *qb.ConditionExpression) errors.TracerError {
+	return db.HardDeleteWhereContext(context.Background(), obj, where)
+}
+
+// HardDeleteWhereContext removes row(s) from the database based on a supplied where clause even if the Record's table
+// is a qb.SoftDeleteTable, the delete is cancelled with the context
+func (db *Database) HardDeleteWhereContext(ctx context.Context, obj Record,
+	where *qb.ConditionExpression) errors.TracerError {
+	return db.InTxContext(ctx, func(tx *sqlx.Tx) error {
+		return db.HardDeleteWhereTxContext(ctx, obj, tx, where)
+	})
+}
+
+// HardDeleteWhereTx removes row(s) from the database based on a supplied where clause in a transaction even if the
+// Record's table is a qb.SoftDeleteTable
+func (db *Database) HardDeleteWhereTx(obj Record, tx *sqlx.Tx, condition *qb.ConditionExpression) errors.TracerError {
+	return db.HardDeleteWhereTxContext(context.Background(), obj, tx, condition)
+}
+
+// Restore clears the soft delete column of a Record and populates it from the database
+func (db *Database) Restore(obj Record) errors.TracerError {
+	return db.RestoreContext(context.Background(), obj)
+}
+
+// RestoreContext clears the soft delete column of a Record and populates it from the database, the update is
+// cancelled with the context
+func (db *Database) RestoreContext(ctx context.Context, obj Record) errors.TracerError {
+	return db.InTxContext(ctx, func(tx *sqlx.Tx) error {
+		return db.RestoreTxContext(ctx, obj, tx)
+	})
+}
+
+// RestoreTx clears the soft delete column of a Record and populates it from the database using a transaction
+func (db *Database) RestoreTx(obj Record, tx *sqlx.Tx) errors.TracerError {
+	return db.RestoreTxContext(context.Background(), obj, tx)
+}
+
+// RestoreTxContext clears the soft delete column of a Record and populates it from the database using a transaction
+func (db *Database) RestoreTxContext(ctx context.Context, obj Record, tx *sqlx.Tx) errors.TracerError {
+	meta, ok := obj.Meta().(qb.SoftDeleteTable)
+	if !ok {
+		return NewValidationError("%s does not support soft delete", obj.Meta().GetName())
+	}
+	stmt, values, err := qb.Update(meta).
+		Set(meta.SoftDelete(), qb.SQLNull).
+		Where(meta.PrimaryKey().Equal(obj.PrimaryKey().Value())).
+		Dialect(db.Dialect).
+		SQL(qb.NoLimit)
+	if nil != err {
+		return errors.Wrap(err)
+	}
+	if _, err = db.execContext(ctx, tx, meta.GetName(), Update, stmt, values...); nil != err {
+		return TranslateError(err, Update, stmt, db.Logger)
+	}
+	return db.ReadTxContext(ctx, obj, obj.PrimaryKey(), tx)
+}
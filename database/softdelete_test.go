@@ -0,0 +1,102 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/generator"
+)
+
+func TestSoftDelete(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	record := &TestArchiveRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.Create(record))
+	assert.False(record.DeletedOn.Valid)
+
+	assert.NoError(spec.DB.Delete(record))
+	assert.IsType(&NotFoundError{}, spec.DB.Read(&TestArchiveRecord{}, record.PrimaryKey()))
+	assert.IsType(&NotFoundError{}, spec.DB.ReadOneWhere(&TestArchiveRecord{}, TestArchiveMeta.Name.Equal(record.Name)))
+	actual := []TestArchiveRecord{}
+	assert.NoError(spec.DB.ListWhere(&TestArchiveRecord{}, &actual, TestArchiveMeta.Name.Equal(record.Name)))
+	assert.Empty(actual)
+
+	deleted := &TestArchiveRecord{}
+	assert.NoError(spec.DB.WithDeleted().Read(deleted, record.PrimaryKey()))
+	assert.True(deleted.DeletedOn.Valid)
+	assert.NoError(spec.DB.WithDeleted().ListWhere(&TestArchiveRecord{}, &actual, TestArchiveMeta.Name.Equal(record.Name)))
+	assert.Len(actual, 1)
+
+	assert.NoError(spec.DB.Restore(record))
+	assert.False(record.DeletedOn.Valid)
+	assert.NoError(spec.DB.Read(&TestArchiveRecord{}, record.PrimaryKey()))
+
+	assert.NoError(spec.DB.HardDelete(record))
+	assert.IsType(&NotFoundError{}, spec.DB.WithDeleted().Read(&TestArchiveRecord{}, record.PrimaryKey()))
+}
+
+func TestSoftDeleteWhere(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	name := generator.Name()
+	records := []*TestArchiveRecord{{Name: name + "a"}, {Name: name + "b"}}
+	for _, record := range records {
+		assert.NoError(spec.DB.Create(record))
+	}
+	assert.NoError(spec.DB.DeleteWhere(&TestArchiveRecord{}, TestArchiveMeta.Name.StartsWith(name)))
+	actual := []TestArchiveRecord{}
+	assert.NoError(spec.DB.WithDeleted().ListWhere(&TestArchiveRecord{}, &actual, TestArchiveMeta.Name.StartsWith(name)))
+	assert.Len(actual, 2)
+
+	assert.NoError(spec.DB.HardDeleteWhere(&TestArchiveRecord{}, TestArchiveMeta.Name.StartsWith(name)))
+	assert.NoError(spec.DB.WithDeleted().ListWhere(&TestArchiveRecord{}, &actual, TestArchiveMeta.Name.StartsWith(name)))
+	assert.Empty(actual)
+}
+
+func TestRestoreNotSoftDelete(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+	assert.IsType(&ValidationError{}, spec.DB.Restore(&TestRecord{ID: "foo"}))
+}
+
+func TestSoftDeleteReusesCondition(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	record := &TestArchiveRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.Create(record))
+	assert.NoError(spec.DB.Delete(record))
+
+	condition := TestArchiveMeta.Name.Equal(record.Name)
+	conditionSQL, _ := condition.SQL()
+	actual := []TestArchiveRecord{}
+	assert.NoError(spec.DB.ListWhere(&TestArchiveRecord{}, &actual, condition))
+	assert.Empty(actual)
+	assert.NoError(spec.DB.WithDeleted().ListWhere(&TestArchiveRecord{}, &actual, condition))
+	assert.Len(actual, 1)
+	actualSQL, _ := condition.SQL()
+	assert.Equal(conditionSQL, actualSQL)
+
+	assert.NoError(spec.DB.HardDeleteWhere(&TestArchiveRecord{}, condition))
+}
+
+func TestNotDeleted(t *testing.T) {
+	assert := assert.New(t)
+	db := &Database{}
+
+	condition := TestArchiveMeta.Name.Equal("foo")
+	expected, _ := condition.SQL()
+	actual, values := db.notDeleted(TestArchiveMeta, condition).SQL()
+	assert.Equal("(`test_archive`.`name` = ? AND `test_archive`.`deleted_on` IS NULL)", actual)
+	assert.Equal([]interface{}{"foo"}, values)
+	actual, _ = condition.SQL()
+	assert.Equal(expected, actual)
+
+	actual, _ = db.notDeleted(TestArchiveMeta, nil).SQL()
+	assert.Equal("`test_archive`.`deleted_on` IS NULL", actual)
+	assert.Equal(condition, db.WithDeleted().notDeleted(TestArchiveMeta, condition))
+	assert.Equal(condition, db.notDeleted(TestMeta, condition))
+}
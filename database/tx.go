@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Kasita-Inc/gadget/errors"
+	"github.com/Kasita-Inc/gadget/net"
+)
+
+// TxFunc is executed within a transaction, returning an error rolls back the work it has done
+type TxFunc func(tx *sqlx.Tx) error
+
+// TxRetry configures how InTx retries a transaction that fails due to a deadlock or lock wait timeout
+type TxRetry struct {
+	// MaxTries is the number of times the transaction is attempted, 1 disables retries
+	MaxTries int
+	// MinimumCycle is the minimum time to wait before retrying
+	MinimumCycle time.Duration
+	// MaxCycle is the maximum time to wait before retrying
+	MaxCycle time.Duration
+}
+
+// DefaultTxRetry is used by InTx when the Database does not have a TxRetry
+var DefaultTxRetry = TxRetry{MaxTries: 3, MinimumCycle: 10 * time.Millisecond, MaxCycle: time.Second}
+
+// savepoints is used to generate unique savepoint names for nested transactions
+var savepoints uint64
+
+// InTx executes fn in a new transaction that is committed when fn returns nil and rolled back otherwise. The
+// transaction is retried with backoff when it fails due to a deadlock or lock wait timeout, so fn may be called more
+// than once.
+func (db *Database) InTx(fn TxFunc) errors.TracerError {
+	return db.InTxContext(context.Background(), fn)
+}
+
+// InTxContext executes fn in a new transaction that is committed when fn returns nil and rolled back otherwise or when
+// the context is done. The transaction is retried with backoff when it fails due to a deadlock or lock wait timeout,
+// so fn may be called more than once.
+func (db *Database) InTxContext(ctx context.Context, fn TxFunc) errors.TracerError {
+	retry := DefaultTxRetry
+	if nil != db.TxRetry {
+		retry = *db.TxRetry
+	}
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for attempt := 1; ; attempt++ {
+		err := db.runTx(ctx, fn)
+		if nil == err || !isRetryable(err) || attempt >= retry.MaxTries {
+			return err
+		}
+		db.Logger.Warnf("retrying transaction (attempt %d): %s", attempt, err)
+		select {
+		case <-ctx.Done():
+			return TranslateError(ctx.Err(), Transaction, "BEGIN", db.Logger)
+		case <-time.After(net.CalculateBackoff(r, attempt, retry.MinimumCycle, retry.MaxCycle)):
+		}
+	}
+}
+
+func (db *Database) runTx(ctx context.Context, fn TxFunc) errors.TracerError {
+	tx, tracerErr := db.BeginContext(ctx)
+	if nil != tracerErr {
+		return tracerErr
+	}
+	defer func() {
+		if p := recover(); nil != p {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(tx); nil != err {
+		tx.Rollback()
+		return db.translateTxError(err)
+	}
+	if err := ctx.Err(); nil != err {
+		tx.Rollback()
+		return TranslateError(err, Transaction, "COMMIT", db.Logger)
+	}
+	if err := tx.Commit(); nil != err {
+		return TranslateError(err, Transaction, "COMMIT", db.Logger)
+	}
+	return nil
+}
+
+// NestedTx executes fn within a SAVEPOINT of the passed transaction so that only the work done by fn is rolled back
+// when it returns an error. When tx is nil fn is executed in a new transaction using InTx. Helpers that accept a
+// transaction use NestedTx to compose safely with the transactions of their callers.
+func (db *Database) NestedTx(tx *sqlx.Tx, fn TxFunc) errors.TracerError {
+	return db.NestedTxContext(context.Background(), tx, fn)
+}
+
+// NestedTxContext executes fn within a SAVEPOINT of the passed transaction so that only the work done by fn is rolled
+// back when it returns an error. When tx is nil fn is executed in a new transaction using InTxContext.
+func (db *Database) NestedTxContext(ctx context.Context, tx *sqlx.Tx, fn TxFunc) errors.TracerError {
+	if nil == tx {
+		return db.InTxContext(ctx, fn)
+	}
+	savepoint := fmt.Sprintf("sp_%d", atomic.AddUint64(&savepoints, 1))
+	if _, err := db.execContext(ctx, tx, "", Transaction, "SAVEPOINT "+savepoint); nil != err {
+		return TranslateError(err, Transaction, "SAVEPOINT "+savepoint, db.Logger)
+	}
+	defer func() {
+		if p := recover(); nil != p {
+			db.execContext(context.Background(), tx, "", Transaction, "ROLLBACK TO SAVEPOINT "+savepoint)
+			panic(p)
+		}
+	}()
+	if err := fn(tx); nil != err {
+		tracerErr := db.translateTxError(err)
+		// a deadlock rolls back the entire transaction so there is no savepoint to return to
+		if _, deadlock := tracerErr.(*DeadlockError); !deadlock {
+			if _, err = db.execContext(ctx, tx, "", Transaction, "ROLLBACK TO SAVEPOINT "+savepoint); nil != err {
+				return TranslateError(err, Transaction, "ROLLBACK TO SAVEPOINT "+savepoint, db.Logger)
+			}
+		}
+		return tracerErr
+	}
+	if _, err := db.execContext(ctx, tx, "", Transaction, "RELEASE SAVEPOINT "+savepoint); nil != err {
+		return TranslateError(err, Transaction, "RELEASE SAVEPOINT "+savepoint, db.Logger)
+	}
+	return nil
+}
+
+// translateTxError converts driver errors returned directly by a TxFunc, other errors are wrapped
+func (db *Database) translateTxError(err error) errors.TracerError {
+	if _, ok := err.(*mysql.MySQLError); ok {
+		return TranslateError(err, Transaction, "", db.Logger)
+	}
+	if _, ok := sqliteErrorCode(err); ok {
+		return TranslateError(err, Transaction, "", db.Logger)
+	}
+	return errors.Wrap(err)
+}
+
+func isRetryable(err error) bool {
+	switch err.(type) {
+	case *DeadlockError, *LockWaitTimeoutError:
+		return true
+	}
+	return false
+}
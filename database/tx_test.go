@@ -0,0 +1,102 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/errors"
+	"github.com/Kasita-Inc/gadget/generator"
+	"github.com/Kasita-Inc/gadget/log"
+)
+
+func TestIsRetryable(t *testing.T) {
+	assert := assert.New(t)
+	logger := log.NewStackLogger()
+	assert.True(isRetryable(NewDeadlockError(Update, "", errors.New("foo"), logger)))
+	assert.True(isRetryable(NewLockWaitTimeoutError(Update, "", errors.New("foo"), logger)))
+	assert.False(isRetryable(NewDuplicateRecordError(Update, "", errors.New("foo"), logger)))
+	assert.False(isRetryable(errors.New("foo")))
+}
+
+func TestInTx(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	record := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.InTx(func(tx *sqlx.Tx) error {
+		return spec.DB.CreateTx(record, tx)
+	}))
+	assert.NoError(spec.DB.Read(&TestRecord{}, record.PrimaryKey()))
+}
+
+func TestInTxRollback(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	record := &TestRecord{Name: generator.Name()}
+	err := spec.DB.InTx(func(tx *sqlx.Tx) error {
+		assert.NoError(spec.DB.CreateTx(record, tx))
+		return errors.New("foo")
+	})
+	assert.EqualError(err, "foo")
+	assert.IsType(&NotFoundError{}, spec.DB.Read(&TestRecord{}, record.PrimaryKey()))
+}
+
+func TestInTxRetry(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+	spec.DB.TxRetry = &TxRetry{MaxTries: 3, MinimumCycle: time.Millisecond, MaxCycle: time.Millisecond}
+
+	calls := 0
+	record := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.InTx(func(tx *sqlx.Tx) error {
+		calls++
+		if calls < 3 {
+			return &mysql.MySQLError{Number: mysqlDeadlock}
+		}
+		return spec.DB.CreateTx(record, tx)
+	}))
+	assert.Equal(3, calls)
+	assert.NoError(spec.DB.Read(&TestRecord{}, record.PrimaryKey()))
+
+	calls = 0
+	err := spec.DB.InTx(func(tx *sqlx.Tx) error {
+		calls++
+		return &mysql.MySQLError{Number: mysqlLockWaitTimeout}
+	})
+	assert.IsType(&LockWaitTimeoutError{}, err)
+	assert.Equal(3, calls)
+}
+
+func TestNestedTx(t *testing.T) {
+	assert := assert.New(t)
+	spec := newSpecification()
+
+	outer := &TestRecord{Name: generator.Name()}
+	inner := &TestRecord{Name: generator.Name()}
+	assert.NoError(spec.DB.InTx(func(tx *sqlx.Tx) error {
+		if err := spec.DB.CreateTx(outer, tx); nil != err {
+			return err
+		}
+		err := spec.DB.NestedTx(tx, func(tx *sqlx.Tx) error {
+			assert.NoError(spec.DB.CreateTx(inner, tx))
+			return spec.DB.NestedTx(tx, func(tx *sqlx.Tx) error {
+				return errors.New("foo")
+			})
+		})
+		assert.EqualError(err, "foo")
+		return nil
+	}))
+	assert.NoError(spec.DB.Read(&TestRecord{}, outer.PrimaryKey()))
+	assert.IsType(&NotFoundError{}, spec.DB.Read(&TestRecord{}, inner.PrimaryKey()))
+
+	// without a transaction NestedTx starts one
+	assert.NoError(spec.DB.NestedTx(nil, func(tx *sqlx.Tx) error {
+		return spec.DB.CreateTx(inner, tx)
+	}))
+	assert.NoError(spec.DB.Read(&TestRecord{}, inner.PrimaryKey()))
+}
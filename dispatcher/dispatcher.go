@@ -1,12 +1,14 @@
 package dispatcher
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/Kasita-Inc/gadget/errors"
 	"github.com/Kasita-Inc/gadget/intutil"
 	"github.com/Kasita-Inc/gadget/log"
 	"github.com/Kasita-Inc/gadget/timeutil"
@@ -47,16 +49,47 @@ type Dispatcher interface {
 	// if the queue is not currently full and false otherwise. If the queue is full,
 	// increase the number of workers or run the dispatcher.
 	Dispatch(task Task) bool
-	// Quit running and stop all workers.
+	// DispatchWithResult dispatches the task like Dispatch and returns a Future
+	// for waiting on its result.
+	DispatchWithResult(task Task) Future
+	// DispatchAt dispatches the task when the time is reached and returns a Future
+	// for waiting on its result. Cancelling the Future before the time removes
+	// the task. The task is accepted even if the dispatcher is not running, it is
+	// dispatched once the dispatcher runs.
+	DispatchAt(at time.Time, task Task) Future
+	// DispatchAfter dispatches the task once the delay has passed, see DispatchAt.
+	DispatchAfter(delay time.Duration, task Task) Future
+	// DispatchCron dispatches the task each time the cron expression is due, see
+	// timeutil.ParseCron, until the returned Recurring is stopped.
+	DispatchCron(spec string, task Task) (Recurring, errors.TracerError)
+	// DispatchSchedule dispatches the task each time the schedule is due until the
+	// returned Recurring is stopped. Runs that are due while the dispatcher is
+	// draining are skipped and runs missed while it is not running are dispatched
+	// once when it runs. An execution may overlap the previous one when it takes
+	// longer than the schedule.
+	DispatchSchedule(schedule timeutil.Schedule, task Task) Recurring
+	// SetWeight of the key for tasks that implement KeyedTask, the number of
+	// consecutive tasks of the key that are executed before the tasks of the
+	// next key when tasks of several keys are waiting. Defaults to 1.
+	SetWeight(key string, weight int)
+	// SetDeadLetterSink that receives the tasks implementing RetryableTask that
+	// failed and will not be retried. Without a sink they are only logged.
+	SetDeadLetterSink(sink DeadLetterSink)
+	// Quit running and stop all workers. When drain is false the context of the
+	// executing tasks is cancelled instead of waiting for them to complete. When
+	// drain is true the executing tasks and the tasks dispatched with a delay,
+	// including retries, are waited for, otherwise delayed tasks are kept until
+	// the dispatcher runs again.
 	Quit(drain bool)
 }
 
 type dispatcher struct {
-	// buffered up to the maximum amount of queued messages
-	queue      chan *internalTask
+	// waiting tasks ordered by priority and key
+	scheduler *scheduler
+	// signals that tasks were added to the scheduler
+	ready      chan bool
 	pool       chan Worker
 	complete   chan *internalTask
-	overflow   TaskStack
 	workers    []Worker
 	exit       chan bool
 	exited     chan bool
@@ -73,11 +106,23 @@ type dispatcher struct {
 	consecutiveScaleDownMisses int
 	etMux                      sync.Mutex
 	executingTasks             map[string]*internalTask
+	// ctx is passed to executing tasks and cancelled when the dispatcher quits
+	ctx    context.Context
+	cancel context.CancelFunc
+	// delayed, recurring and retried tasks waiting to be dispatched
+	timers *timers
+	// number of tasks passed to workers that have not returned, a drain waits for them
+	executing  int32
+	deadLetter DeadLetterSink
 }
 
 // NewDispatcher to handle asynchronous processing of Tasks with the specified maximum number of workers.
 // In order to use the dispatcher to process work, callers must implement the `Task` interface.
 // Execution for the dispatcher is asynchronous but `Dispatcher.Run` must be called for any tasks to be worked.
+// Tasks that implement `ContextTask` are cancelled when they exceed their timeout, `DefaultTaskTimeout` unless they
+// implement `TimeoutTask`, or when the dispatcher quits without draining. Every task holds its worker until it returns.
+// Tasks that implement `RetryableTask`, see `WithRetry`, are dispatched again when they fail.
+// Tasks are dispatched later with `DispatchAt` and `DispatchAfter` or repeatedly with `DispatchCron`.
 // Example Usage:
 //
 //      type MyTask struct {}
@@ -99,11 +144,12 @@ type dispatcher struct {
 func NewDispatcher(maxBufferedMessage int, minWorkers int, maxWorkers int) Dispatcher {
 	d := &dispatcher{
 		bufferSize: maxBufferedMessage,
-		queue:      make(chan *internalTask, maxBufferedMessage),
+		scheduler:  newScheduler(),
+		timers:     newTimers(),
+		ready:      make(chan bool, 1),
 		complete:   make(chan *internalTask, maxBufferedMessage),
 		// don't set min below 0
 		minWorkers:                 intutil.Maxv(0, minWorkers),
-		overflow:                   NewTaskStack(),
 		waitBetweenScaleDowns:      DefaultWaitBetweenScaleDowns,
 		consecutiveScaleDownMisses: DefaultDispatchMissesBeforeDraining,
 		executingTasks:             make(map[string]*internalTask),
@@ -113,9 +159,8 @@ func NewDispatcher(maxBufferedMessage int, minWorkers int, maxWorkers int) Dispa
 	return d
 }
 
-func (d *dispatcher) overflowPush(t *internalTask) {
-	d.overflow.Push(t)
-	if d.overflow.Size()%20 == 0 {
+func (d *dispatcher) logOverflow(overflow int) {
+	if overflow%20 == 0 {
 		d.etMux.Lock()
 		ets := make([]string, len(d.executingTasks))
 		i := 0
@@ -128,6 +173,14 @@ func (d *dispatcher) overflowPush(t *internalTask) {
 	}
 }
 
+// context returns the context that tasks are executed with
+func (d *dispatcher) context() context.Context {
+	if nil == d.ctx {
+		return context.Background()
+	}
+	return d.ctx
+}
+
 func (d *dispatcher) Status() Status {
 	return Status(atomic.LoadInt32(&d.running))
 }
@@ -161,7 +214,7 @@ func (d *dispatcher) Resize(size int, start bool) {
 		d.workers = make([]Worker, size)
 		// add in the new workers
 		for i := 0; i < len(d.workers); i++ {
-			d.workers[i] = NewWorker(newPool, d.complete)
+			d.workers[i] = newWorker(d.context(), newPool, d.complete)
 		}
 		// no one is using the old pool anymore so close it
 		if nil != d.pool {
@@ -180,31 +233,123 @@ func (d *dispatcher) Resize(size int, start bool) {
 
 // Dispatch is non-blocking and will accept buffer tasks for asynchronous execution
 // by this dispatchers workers up to the maximum buffered tasks. If the maximum buffered
-// tasks is reached and a non-blocking put cannot succeed on the task queue an overflow buffer
-// will be used to store the additional tasks which will be loaded onto the queue as space becomes available,
-// this will have negative performance implications.
+// tasks is reached the task is still accepted and scheduled in the same order, but a
+// warning is logged and false is returned.
 // If overflow occurs regularly in production set max buffered tasks to a higher value
 // when initializing the dispatcher.
+// Waiting tasks are executed in order of priority, see PriorityTask, and then in weighted
+// round-robin across their keys, see KeyedTask, in the order they were dispatched.
 // NOTE: If this dispatcher is currently draining you should not dispatch more tasks, as this will
 // prevent the Quit function from exiting.
 func (d *dispatcher) Dispatch(task Task) bool {
 	if d.Status() == Draining {
 		log.Error(fmt.Errorf("task added to dispatcher while draining: %+v", task))
 	}
-	return d.enqueue(newInternalTask(task), false)
+	return d.enqueue(d.newTask(task))
 }
 
-func (d *dispatcher) enqueue(task *internalTask, suppressWarning bool) bool {
-	select {
-	case d.queue <- task:
-		return true
-	default:
-		d.overflowPush(task)
-		if !suppressWarning {
-			log.Warnf("task added to dispatcher with a full queue, overflow is at %d ", d.overflow.Size())
+func (d *dispatcher) DispatchWithResult(task Task) Future {
+	t := d.newTask(task)
+	t.future = newFuture()
+	if d.Status() == Draining {
+		log.Error(fmt.Errorf("task added to dispatcher while draining: %+v", task))
+	}
+	d.enqueue(t)
+	return t.future
+}
+
+func (d *dispatcher) DispatchAt(at time.Time, task Task) Future {
+	t := d.newTask(task)
+	t.future = newFuture()
+	if d.Status() == Draining {
+		log.Error(fmt.Errorf("task added to dispatcher while draining: %+v", task))
+	}
+	timer := d.timers.Once(at, t)
+	t.future.onCancel = timer.Stop
+	return t.future
+}
+
+func (d *dispatcher) DispatchAfter(delay time.Duration, task Task) Future {
+	return d.DispatchAt(time.Now().Add(delay), task)
+}
+
+func (d *dispatcher) DispatchCron(spec string, task Task) (Recurring, errors.TracerError) {
+	schedule, err := timeutil.ParseCron(spec)
+	if nil != err {
+		return nil, err
+	}
+	return d.DispatchSchedule(schedule, task), nil
+}
+
+func (d *dispatcher) DispatchSchedule(schedule timeutil.Schedule, task Task) Recurring {
+	timer, ok := d.timers.Every(schedule, task)
+	if !ok {
+		log.Warnf("schedule for task %+v is never due", task)
+	}
+	return timer
+}
+
+func (d *dispatcher) SetWeight(key string, weight int) {
+	d.scheduler.SetWeight(key, weight)
+}
+
+func (d *dispatcher) SetDeadLetterSink(sink DeadLetterSink) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.deadLetter = sink
+}
+
+func (d *dispatcher) newTask(task Task) *internalTask {
+	t := newInternalTask(task)
+	t.done = d.taskDone
+	if _, ok := task.(RetryableTask); ok {
+		t.retry = d.retry
+	}
+	return t
+}
+
+// taskDone is called when a task passed to a worker returns, a retry of the task has already been added to the timers
+func (d *dispatcher) taskDone() {
+	if 0 == atomic.AddInt32(&d.executing, -1) {
+		d.resumeDrain()
+	}
+}
+
+// retry the failed task after the delay of its RetryPolicy by adding it to the timers, the worker is released while it
+// waits. Returns false when the task will not be executed again.
+func (d *dispatcher) retry(t *internalTask, err error) bool {
+	if nil == err {
+		return false
+	}
+	if _, ok := t.Error.(*CancelledError); ok {
+		return false
+	}
+	delay, ok := t.Task.(RetryableTask).RetryPolicy().Next(t.attempts, time.Since(t.firstStart), err)
+	if !ok {
+		d.mutex.RLock()
+		sink := d.deadLetter
+		d.mutex.RUnlock()
+		if nil == sink {
+			log.Errorf("task %s failed after %d attempts: %s", t.ID, t.attempts, t.Error)
+		} else {
+			sink.DeadLetter(t.Task, t.Error, t.attempts)
 		}
 		return false
 	}
+	d.timers.Once(time.Now().Add(delay), t)
+	return true
+}
+
+func (d *dispatcher) enqueue(task *internalTask) bool {
+	d.scheduler.Push(task)
+	sendNonBlocking(true, d.ready)
+	overflow := d.scheduler.Size() - d.bufferSize
+	if overflow <= 0 {
+		return true
+	}
+	log.Warnf("task added to dispatcher with a full queue, overflow is at %d ", overflow)
+	d.logOverflow(overflow)
+	return false
 }
 
 func sendNonBlocking(value bool, ch chan bool) bool {
@@ -216,25 +361,13 @@ func sendNonBlocking(value bool, ch chan bool) bool {
 	}
 }
 
-func (d *dispatcher) loadOverflow() bool {
-	if d.overflow.Size() == 0 {
-		return true
-	}
-	for t, e := d.overflow.Pop(); nil == e; t, e = d.overflow.Pop() {
-		// we know we are probably going to hit overflow, so suppress the warning
-		if !d.enqueue(t, true) {
-			return false
-		}
-	}
-	return true
-}
-
 func (d *dispatcher) Run() {
 	// run while draining or stopping would cause all kinds of problems
 	if d.Status() != Stopped {
 		return
 	}
 	atomic.StoreInt32(&d.running, int32(Running))
+	d.ctx, d.cancel = context.WithCancel(context.Background())
 	d.drain = make(chan bool, 2)
 	d.exit = make(chan bool, 2)
 	d.exited = make(chan bool)
@@ -249,15 +382,27 @@ func (d *dispatcher) run() {
 	var lastDispatch time.Time
 	ticker := timeutil.NewTicker(d.waitBetweenScaleDowns).Start()
 	defer ticker.Stop()
+	// a single timer for the earliest of the delayed and recurring tasks
+	clock := time.NewTimer(time.Hour)
+	defer clock.Stop()
+	d.timers.arm(clock)
 	for {
+		// only wait for a worker when there are tasks to execute
+		var pool chan Worker
+		if d.scheduler.Size() > 0 {
+			pool = d.pool
+		}
 		select {
 		case <-d.exit:
 			d.exited <- true
 			return
 		case <-d.drain:
 			consecutiveMisses++
-			// try to load the overflow
-			if d.overflow.Size() == 0 && consecutiveMisses > d.consecutiveScaleDownMisses {
+			if d.timers.Pending() > 0 || atomic.LoadInt32(&d.executing) > 0 {
+				// delayed or executing tasks may still add tasks, draining resumes when they are dispatched or return
+				continue
+			}
+			if d.scheduler.Size() == 0 && consecutiveMisses > d.consecutiveScaleDownMisses {
 				log.Infof("exiting as there are no more tasks")
 				d.exited <- true
 				return
@@ -269,53 +414,71 @@ func (d *dispatcher) run() {
 			delete(d.executingTasks, task.ID)
 			d.etMux.Unlock()
 			consecutiveMisses = 0
-			if d.overflow.Size() > 0 && !d.loadOverflow() {
-				log.Infof("dispatcher overflow queue at %d messages after load", d.overflow.Size())
+		case <-d.timers.wake:
+			d.timers.arm(clock)
+			d.resumeDrain()
+		case <-clock.C:
+			for _, task := range d.timers.Due(time.Now(), d.Status() == Running, d.newTask) {
+				d.enqueue(task)
 			}
-		case task := <-d.queue:
+			d.timers.arm(clock)
+			d.resumeDrain()
+		case <-d.ready:
 			lastDispatch = time.Now()
 			consecutiveMisses = 0
-			d.dispatch(task)
+			d.scaleUp()
+		case w, ok := <-pool:
+			if ok {
+				lastDispatch = time.Now()
+				consecutiveMisses = 0
+				d.dispatch(w)
+				d.scaleUp()
+			}
 		case <-ticker.Channel():
-			// scale down if we have no overflow queue, we are not at our minimum number of workers
+			// scale down if we have no waiting tasks, we are not at our minimum number of workers
 			// and it has been over a second since the last time we dispatched a message
-			if d.overflow.Size() == 0 && len(d.workers) != d.minWorkers && time.Since(lastDispatch) > d.waitBetweenScaleDowns {
-				log.Infof("dispatcher status: %d workers %d overflow", len(d.workers), d.overflow.Size())
+			if d.scheduler.Size() == 0 && len(d.workers) != d.minWorkers && time.Since(lastDispatch) > d.waitBetweenScaleDowns {
+				log.Infof("dispatcher status: %d workers %d waiting", len(d.workers), d.scheduler.Size())
 				d.Resize(len(d.workers)/2, true)
 			}
 		}
 	}
 }
 
-func (d *dispatcher) dispatch(t *internalTask) {
-	select {
-	// wait for a worker to become available
-	case w := <-d.pool:
-		// this should only return false when we somehow got a worker that
-		// is not accepting requests
-		// success = w.Exec(t)
+// resumeDrain after waiting for delayed tasks
+func (d *dispatcher) resumeDrain() {
+	if d.Status() == Draining {
+		sendNonBlocking(true, d.drain)
+	}
+}
+
+// scaleUp the pool when tasks are waiting and there are no idle workers, if we are not already at capacity
+func (d *dispatcher) scaleUp() {
+	if d.scheduler.Size() > 0 && len(d.pool) == 0 && len(d.workers) != d.maxWorkers {
+		log.Infof("No workers available scaling pool")
+		d.Resize(intutil.Maxv(1, 2*len(d.workers)), true)
+	}
+}
+
+// dispatch the next waiting task to the worker
+func (d *dispatcher) dispatch(w Worker) {
+	t, ok := d.scheduler.Pop()
+	if !ok {
+		return
+	}
+	d.etMux.Lock()
+	d.executingTasks[t.ID] = t
+	d.etMux.Unlock()
+	atomic.AddInt32(&d.executing, 1)
+	if !w.Exec(t) {
+		atomic.AddInt32(&d.executing, -1)
+		// this should only happen when we somehow got a worker that
+		// is not accepting requests, keep the task's place in line
+		log.Warnf("worker exec failed, requeueing task (%d tasks)", d.scheduler.Size())
 		d.etMux.Lock()
-		d.executingTasks[t.ID] = t
+		delete(d.executingTasks, t.ID)
 		d.etMux.Unlock()
-		if !w.Exec(t) {
-			// put it in overflow for now
-			log.Warnf("worker exec failed, pushing task to overflow (%d tasks)", d.overflow.Size())
-			d.overflowPush(t)
-		}
-	case <-d.exit:
-		// add another true onto the channel so callers above us get the message
-		// and then bail
-		d.exit <- true
-		return
-	default:
-		// no workers, try scaling up if we are not already at capacity
-		if len(d.workers) != d.maxWorkers {
-			log.Infof("No workers available scaling pool")
-			d.Resize(2*len(d.workers), true)
-		}
-		// but either way push to overflow and try later
-		d.overflowPush(t)
-
+		d.scheduler.Requeue(t)
 	}
 }
 
@@ -327,10 +490,13 @@ func (d *dispatcher) Quit(drain bool) {
 			d.drain <- true
 		} else {
 			atomic.StoreInt32(&d.running, int32(Stopping))
+			// cancel the executing tasks so that the workers are not held
+			d.cancel()
 			d.exit <- true
 		}
 		<-d.exited
 		d.Resize(0, false)
+		d.cancel()
 		// if we set this prior to being done, a run command will break things.
 		atomic.StoreInt32(&d.running, int32(Stopped))
 	}
@@ -1,6 +1,7 @@
 package dispatcher
 
 import (
+	"context"
 	"strconv"
 	"sync/atomic"
 	"testing"
@@ -167,3 +168,20 @@ func TestQuitDrainDrains(t *testing.T) {
 	}
 	assert.Equal(taskCount, atomic.LoadInt32(&completedTasks))
 }
+
+func TestQuitCancelsExecutingTasks(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	d.Run()
+	started := make(chan bool)
+	cancelled := make(chan error, 1)
+	d.Dispatch(TaskFunc(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return ctx.Err()
+	}))
+	<-started
+	d.Quit(false)
+	assert.Equal(context.Canceled, <-cancelled)
+}
@@ -0,0 +1,45 @@
+package dispatcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+// TimeoutError is recorded for a task that did not complete before its timeout
+type TimeoutError struct {
+	Timeout time.Duration
+	trace   []string
+}
+
+func (err *TimeoutError) Error() string {
+	return fmt.Sprintf("task timed out after %s", err.Timeout)
+}
+
+// Trace returns the stack trace for the error
+func (err *TimeoutError) Trace() []string {
+	return err.trace
+}
+
+// NewTimeoutError instantiates a TimeoutError with a stack trace
+func NewTimeoutError(timeout time.Duration) errors.TracerError {
+	return &TimeoutError{Timeout: timeout, trace: errors.GetStackTrace()}
+}
+
+// CancelledError is recorded for a task that was cancelled because the dispatcher quit without draining
+type CancelledError struct{ trace []string }
+
+func (err *CancelledError) Error() string {
+	return "task cancelled"
+}
+
+// Trace returns the stack trace for the error
+func (err *CancelledError) Trace() []string {
+	return err.trace
+}
+
+// NewCancelledError instantiates a CancelledError with a stack trace
+func NewCancelledError() errors.TracerError {
+	return &CancelledError{trace: errors.GetStackTrace()}
+}
@@ -0,0 +1,115 @@
+package dispatcher
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+const (
+	futurePending int32 = iota
+	futureRunning
+	futureComplete
+	futureCancelled
+)
+
+// Result of a task executed by the dispatcher
+type Result struct {
+	// StartTime of the last execution, zero when the task was cancelled before it started
+	StartTime time.Time
+	// Duration of the last execution
+	Duration time.Duration
+	// Error returned by the task, a TimeoutError when it timed out or a CancelledError when it was cancelled
+	Error errors.TracerError
+	// Attempts is the number of times the task was executed, more than one when it was retried
+	Attempts int
+}
+
+// Future is the handle for a task dispatched with DispatchWithResult
+type Future interface {
+	// Wait for the task to complete and return its result, or the error of the context if it is done first.
+	Wait(ctx context.Context) (*Result, error)
+	// Done returns a channel that is closed when the task completes or is cancelled.
+	Done() <-chan struct{}
+	// Cancel the task if it has not started executing. Returns true when the task will not be executed, the
+	// result then has a CancelledError.
+	Cancel() bool
+	// OnComplete registers a callback that is called with the result when the task completes, or immediately if it
+	// already has. Callbacks are called on the worker that executed the task and should not block.
+	OnComplete(callback func(*Result))
+}
+
+type future struct {
+	state     int32
+	done      chan struct{}
+	mutex     sync.Mutex
+	result    *Result
+	callbacks []func(*Result)
+	// onCancel is called when the task is cancelled before it was dispatched
+	onCancel func()
+}
+
+func newFuture() *future {
+	return &future{done: make(chan struct{})}
+}
+
+func (f *future) Wait(ctx context.Context) (*Result, error) {
+	select {
+	case <-f.done:
+		return f.result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *future) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *future) Cancel() bool {
+	if !atomic.CompareAndSwapInt32(&f.state, futurePending, futureCancelled) {
+		return false
+	}
+	if nil != f.onCancel {
+		f.onCancel()
+	}
+	f.complete(&Result{Error: NewCancelledError()})
+	return true
+}
+
+func (f *future) OnComplete(callback func(*Result)) {
+	f.mutex.Lock()
+	if nil == f.result {
+		f.callbacks = append(f.callbacks, callback)
+		f.mutex.Unlock()
+		return
+	}
+	f.mutex.Unlock()
+	callback(f.result)
+}
+
+// start marks the task as executing, false when it was cancelled
+func (f *future) start() bool {
+	return atomic.CompareAndSwapInt32(&f.state, futurePending, futureRunning)
+}
+
+// finish records the result of an executed task
+func (f *future) finish(result *Result) {
+	atomic.StoreInt32(&f.state, futureComplete)
+	f.complete(result)
+}
+
+func (f *future) complete(result *Result) {
+	f.mutex.Lock()
+	f.result = result
+	callbacks := f.callbacks
+	f.callbacks = nil
+	close(f.done)
+	f.mutex.Unlock()
+	for _, callback := range callbacks {
+		callback(result)
+	}
+}
@@ -0,0 +1,78 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchWithResult(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	d.Run()
+	defer d.Quit(false)
+
+	expected := fmt.Errorf("failed")
+	callbacks := make(chan *Result, 2)
+	future := d.DispatchWithResult(&GenericTask{execute: func() error {
+		time.Sleep(time.Millisecond)
+		return expected
+	}})
+	future.OnComplete(func(result *Result) { callbacks <- result })
+
+	result, err := future.Wait(context.Background())
+	assert.NoError(err)
+	assert.EqualError(result.Error, "failed")
+	assert.False(result.StartTime.IsZero())
+	assert.True(result.Duration >= time.Millisecond)
+	assert.Equal(result, <-callbacks)
+
+	// callbacks registered after completion are called immediately
+	future.OnComplete(func(result *Result) { callbacks <- result })
+	assert.Equal(result, <-callbacks)
+	assert.False(future.Cancel())
+}
+
+func TestFutureCancel(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+
+	var executed int32
+	future := d.DispatchWithResult(&GenericTask{execute: func() error {
+		atomic.AddInt32(&executed, 1)
+		return nil
+	}})
+	assert.True(future.Cancel())
+	assert.False(future.Cancel())
+	result, err := future.Wait(context.Background())
+	assert.NoError(err)
+	assert.IsType(&CancelledError{}, result.Error)
+
+	d.Run()
+	next := d.DispatchWithResult(&GenericTask{execute: func() error { return nil }})
+	_, err = next.Wait(context.Background())
+	assert.NoError(err)
+	d.Quit(false)
+	assert.Equal(int32(0), atomic.LoadInt32(&executed))
+}
+
+func TestFutureWaitContext(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	future := d.DispatchWithResult(&GenericTask{execute: func() error { return nil }})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	result, err := future.Wait(ctx)
+	assert.Nil(result)
+	assert.Equal(context.DeadlineExceeded, err)
+	select {
+	case <-future.Done():
+		assert.Fail("task should not have completed")
+	default:
+	}
+}
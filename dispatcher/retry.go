@@ -0,0 +1,140 @@
+package dispatcher
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Kasita-Inc/gadget/errors"
+	"github.com/Kasita-Inc/gadget/timeutil"
+)
+
+// DefaultRetryCeiling is the maximum wait between the retries of a task created with NewRetryTask
+const DefaultRetryCeiling = 15 * time.Second
+
+// RetryPolicy decides if and when a task that returned an error is executed again.
+type RetryPolicy interface {
+	// Next returns the delay before the next execution of a task that returned err, or false when it should not be
+	// retried. Attempt is the number of times the task has been executed and elapsed is the time since the first
+	// execution started.
+	Next(attempt int, elapsed time.Duration, err error) (time.Duration, bool)
+}
+
+// RetryPolicyFunc is a RetryPolicy that calls itself
+type RetryPolicyFunc func(attempt int, elapsed time.Duration, err error) (time.Duration, bool)
+
+// Next calls the function
+func (f RetryPolicyFunc) Next(attempt int, elapsed time.Duration, err error) (time.Duration, bool) {
+	return f(attempt, elapsed, err)
+}
+
+type exponentialBackoff struct {
+	maxAttempts  int
+	minimumCycle time.Duration
+	maxCycle     time.Duration
+	mutex        sync.Mutex
+	rand         *rand.Rand
+}
+
+// NewExponentialBackoff retries a task until it has been executed maxAttempts times, waiting with exponential backoff
+// and jitter between executions the same as net.Backoff.
+func NewExponentialBackoff(maxAttempts int, minimumCycle time.Duration, maxCycle time.Duration) RetryPolicy {
+	return &exponentialBackoff{
+		maxAttempts:  maxAttempts,
+		minimumCycle: minimumCycle,
+		maxCycle:     maxCycle,
+		rand:         rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (p *exponentialBackoff) Next(attempt int, elapsed time.Duration, err error) (time.Duration, bool) {
+	if attempt >= p.maxAttempts {
+		return 0, false
+	}
+	// rand.Rand is not safe for concurrent use and the policy is shared by tasks
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return timeutil.CalculateBackoff(p.rand, attempt, p.minimumCycle, p.maxCycle), true
+}
+
+// WithMaxElapsed stops retrying a task when its next execution would start more than maxElapsed after its first.
+func WithMaxElapsed(policy RetryPolicy, maxElapsed time.Duration) RetryPolicy {
+	return RetryPolicyFunc(func(attempt int, elapsed time.Duration, err error) (time.Duration, bool) {
+		delay, ok := policy.Next(attempt, elapsed, err)
+		if !ok || elapsed+delay > maxElapsed {
+			return 0, false
+		}
+		return delay, true
+	})
+}
+
+// WithRetryOn only retries a task when retryOn returns true for the error it returned.
+func WithRetryOn(policy RetryPolicy, retryOn func(err error) bool) RetryPolicy {
+	return RetryPolicyFunc(func(attempt int, elapsed time.Duration, err error) (time.Duration, bool) {
+		if !retryOn(err) {
+			return 0, false
+		}
+		return policy.Next(attempt, elapsed, err)
+	})
+}
+
+// RetryableTask is a Task that the dispatcher executes again according to its RetryPolicy when it returns an error.
+// Retries are re-dispatched after their delay rather than waiting on a worker, and a task that will not be retried is
+// sent to the DeadLetterSink of the dispatcher. Tasks cancelled because the dispatcher quit are not retried.
+type RetryableTask interface {
+	Task
+	// RetryPolicy of the task
+	RetryPolicy() RetryPolicy
+}
+
+// WithRetry returns a RetryableTask that executes the task with the policy. The returned task keeps the timeout,
+// priority and key of the task.
+func WithRetry(task Task, policy RetryPolicy) RetryableTask {
+	return &retryableTask{base: task, policy: policy}
+}
+
+type retryableTask struct {
+	base   Task
+	policy RetryPolicy
+}
+
+func (rt *retryableTask) Execute() error {
+	return rt.base.Execute()
+}
+
+func (rt *retryableTask) ExecuteContext(ctx context.Context) error {
+	return executeTask(ctx, rt.base)
+}
+
+func (rt *retryableTask) Timeout() time.Duration {
+	return taskTimeout(rt.base)
+}
+
+func (rt *retryableTask) GetPriority() int {
+	return taskPriority(rt.base)
+}
+
+func (rt *retryableTask) GetKey() string {
+	return taskKey(rt.base)
+}
+
+func (rt *retryableTask) RetryPolicy() RetryPolicy {
+	return rt.policy
+}
+
+// DeadLetterSink receives the RetryableTasks that failed and will not be retried, either because they exhausted their
+// RetryPolicy or because their error is not retried.
+type DeadLetterSink interface {
+	// DeadLetter is called with the task, the error of its last execution and the number of times it was executed.
+	// It is called on the worker that executed the task and should not block.
+	DeadLetter(task Task, err errors.TracerError, attempts int)
+}
+
+// DeadLetterFunc is a DeadLetterSink that calls itself
+type DeadLetterFunc func(task Task, err errors.TracerError, attempts int)
+
+// DeadLetter calls the function
+func (f DeadLetterFunc) DeadLetter(task Task, err errors.TracerError, attempts int) {
+	f(task, err, attempts)
+}
@@ -0,0 +1,162 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+func TestRetryPolicies(t *testing.T) {
+	assert := assert.New(t)
+	failed := fmt.Errorf("failed")
+	policy := NewExponentialBackoff(3, 10*time.Millisecond, time.Second)
+	first, ok := policy.Next(1, 0, failed)
+	assert.True(ok)
+	second, ok := policy.Next(2, first, failed)
+	assert.True(ok)
+	assert.True(second > first, "expected %s > %s", second, first)
+	assert.True(second <= time.Second)
+	_, ok = policy.Next(3, first+second, failed)
+	assert.False(ok)
+
+	elapsed := WithMaxElapsed(policy, 500*time.Millisecond)
+	_, ok = elapsed.Next(1, 0, failed)
+	assert.True(ok)
+	_, ok = elapsed.Next(1, 500*time.Millisecond, failed)
+	assert.False(ok)
+
+	retryOn := WithRetryOn(policy, func(err error) bool { return failed == err })
+	_, ok = retryOn.Next(1, 0, failed)
+	assert.True(ok)
+	_, ok = retryOn.Next(1, 0, fmt.Errorf("permanent"))
+	assert.False(ok)
+}
+
+func TestRetryTask(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	task := NewRetryTask(&GenericTask{execute: func() error {
+		atomic.AddInt32(&calls, 1)
+		return fmt.Errorf("failed")
+	}}, func() bool { return true }, 3, time.Millisecond)
+	assert.EqualError(task.Execute(), "failed")
+	assert.Equal(int32(3), atomic.LoadInt32(&calls))
+
+	// success is not retried
+	calls = 0
+	task = NewRetryTask(&GenericTask{execute: func() error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}}, func() bool { return true }, 3, time.Millisecond)
+	assert.NoError(task.Execute())
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestDispatchWithRetry(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	dead := make(chan int, 1)
+	d.SetDeadLetterSink(DeadLetterFunc(func(task Task, err errors.TracerError, attempts int) {
+		dead <- attempts
+	}))
+	d.Run()
+	defer d.Quit(false)
+
+	// a task that succeeds on its third attempt
+	var calls int32
+	task := WithRetry(&GenericTask{execute: func() error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return fmt.Errorf("failed")
+		}
+		return nil
+	}}, NewExponentialBackoff(5, time.Millisecond, 10*time.Millisecond))
+	result, err := d.DispatchWithResult(task).Wait(context.Background())
+	assert.NoError(err)
+	assert.NoError(result.Error)
+	assert.Equal(3, result.Attempts)
+
+	// the worker is free while a task waits for its retry
+	waiting := WithRetry(&GenericTask{execute: func() error {
+		return fmt.Errorf("failed")
+	}}, NewExponentialBackoff(2, time.Second, time.Second))
+	future := d.DispatchWithResult(waiting)
+	result, err = d.DispatchWithResult(&GenericTask{execute: func() error { return nil }}).Wait(context.Background())
+	assert.NoError(err)
+	assert.NoError(result.Error)
+	select {
+	case <-future.Done():
+		assert.Fail("task should be waiting for its retry")
+	default:
+	}
+
+	// a task that exhausts its retries is sent to the dead letter sink
+	task = WithRetry(&GenericTask{execute: func() error {
+		return fmt.Errorf("failed")
+	}}, NewExponentialBackoff(2, time.Millisecond, 10*time.Millisecond))
+	result, err = d.DispatchWithResult(task).Wait(context.Background())
+	assert.NoError(err)
+	assert.EqualError(result.Error, "failed")
+	assert.Equal(2, result.Attempts)
+	assert.Equal(2, <-dead)
+}
+
+func TestDrainWaitsForRetries(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	d.Run()
+	var calls int32
+	d.Dispatch(WithRetry(&GenericTask{execute: func() error {
+		if atomic.AddInt32(&calls, 1) < 2 {
+			return fmt.Errorf("failed")
+		}
+		return nil
+	}}, RetryPolicyFunc(func(attempt int, elapsed time.Duration, err error) (time.Duration, bool) {
+		return 50 * time.Millisecond, true
+	})))
+	// wait for the first attempt to fail
+	internal := d.(*dispatcher)
+	for 0 == internal.timers.Pending() {
+		time.Sleep(time.Millisecond)
+	}
+	d.Quit(true)
+	// the retry was dispatched before quitting
+	assert.Equal(0, internal.timers.Pending())
+	assert.Equal(0, internal.scheduler.Size())
+}
+
+func TestDrainRetriesTaskFailingWhileDraining(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	d.Run()
+	started := make(chan bool, 1)
+	var calls int32
+	future := d.DispatchWithResult(WithRetry(&GenericTask{execute: func() error {
+		if 1 == atomic.AddInt32(&calls, 1) {
+			started <- true
+			// fail once the dispatcher is draining
+			for d.(*dispatcher).Status() != Draining {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(10 * time.Millisecond)
+			return fmt.Errorf("failed")
+		}
+		return nil
+	}}, NewExponentialBackoff(2, time.Millisecond, 10*time.Millisecond)))
+	<-started
+	d.Quit(true)
+	select {
+	case <-future.Done():
+	default:
+		assert.Fail("the retry should complete before quitting")
+	}
+	result, err := future.Wait(context.Background())
+	assert.NoError(err)
+	assert.NoError(result.Error)
+	assert.Equal(2, result.Attempts)
+}
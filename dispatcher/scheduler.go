@@ -0,0 +1,194 @@
+package dispatcher
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/Kasita-Inc/gadget/collection"
+	"github.com/Kasita-Inc/gadget/collection/specialized"
+)
+
+// DefaultKey is the key of tasks that do not implement KeyedTask
+const DefaultKey = ""
+
+// PriorityTask is a Task that is executed before any waiting tasks with a lower priority. Tasks that do not implement
+// PriorityTask have a priority of 0.
+type PriorityTask interface {
+	Task
+	specialized.Priority
+}
+
+// KeyedTask is a Task that is scheduled fairly against the tasks of other keys, such as the tenant the task is for.
+// Waiting tasks of the same priority are executed in weighted round-robin by key and in the order they were
+// dispatched within a key.
+type KeyedTask interface {
+	Task
+	// GetKey of the task used for fair scheduling
+	GetKey() string
+}
+
+func taskPriority(task Task) int {
+	if t, ok := task.(specialized.Priority); ok {
+		return t.GetPriority()
+	}
+	return 0
+}
+
+func taskKey(task Task) string {
+	if t, ok := task.(KeyedTask); ok {
+		return t.GetKey()
+	}
+	return DefaultKey
+}
+
+// scheduler orders waiting tasks by priority and then by weighted round-robin across their keys
+type scheduler struct {
+	mutex sync.Mutex
+	// priorities with waiting tasks from highest to lowest
+	priorities []int
+	levels     map[int]*schedulerLevel
+	weights    map[string]int
+	size       int
+}
+
+// schedulerLevel holds the waiting tasks of a single priority
+type schedulerLevel struct {
+	// keys with waiting tasks in round-robin order
+	keys   []string
+	queues map[string]collection.DList
+	// current is the index of the key tasks are taken from
+	current int
+	// credit is the number of tasks the current key may still run before the next key's turn
+	credit int
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		levels:  make(map[int]*schedulerLevel),
+		weights: make(map[string]int),
+	}
+}
+
+// Size is the number of waiting tasks
+func (s *scheduler) Size() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.size
+}
+
+// SetWeight of the key, the number of consecutive tasks of the key that are executed before moving on to the next
+func (s *scheduler) SetWeight(key string, weight int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if weight <= 1 {
+		delete(s.weights, key)
+	} else {
+		s.weights[key] = weight
+	}
+}
+
+func (s *scheduler) weight(key string) int {
+	if weight, ok := s.weights[key]; ok {
+		return weight
+	}
+	return 1
+}
+
+// Push the task after the waiting tasks with the same priority and key
+func (s *scheduler) Push(task *internalTask) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	queue := s.level(taskPriority(task.Task)).queue(taskKey(task.Task))
+	queue.InsertNext(queue.Tail(), task)
+	s.size++
+}
+
+// Requeue the task ahead of the waiting tasks with the same priority and key, for tasks that were popped but could
+// not be executed
+func (s *scheduler) Requeue(task *internalTask) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	level := s.level(taskPriority(task.Task))
+	queue := level.queue(taskKey(task.Task))
+	queue.InsertPrevious(queue.Head(), task)
+	level.setCurrent(taskKey(task.Task))
+	s.size++
+}
+
+// Pop the next task to execute, false when there are no waiting tasks
+func (s *scheduler) Pop() (*internalTask, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.priorities) == 0 {
+		return nil, false
+	}
+	priority := s.priorities[0]
+	level := s.levels[priority]
+	task := level.pop(s.weight)
+	if len(level.keys) == 0 {
+		delete(s.levels, priority)
+		s.priorities = s.priorities[1:]
+	}
+	s.size--
+	return task, true
+}
+
+// level returns the level for the priority, adding it when there are no waiting tasks of the priority
+func (s *scheduler) level(priority int) *schedulerLevel {
+	if level, ok := s.levels[priority]; ok {
+		return level
+	}
+	level := &schedulerLevel{queues: make(map[string]collection.DList)}
+	s.levels[priority] = level
+	i := sort.Search(len(s.priorities), func(i int) bool { return s.priorities[i] < priority })
+	s.priorities = append(s.priorities, 0)
+	copy(s.priorities[i+1:], s.priorities[i:])
+	s.priorities[i] = priority
+	return level
+}
+
+// queue returns the queue for the key, adding the key at the end of the round-robin when it has no waiting tasks
+func (l *schedulerLevel) queue(key string) collection.DList {
+	if queue, ok := l.queues[key]; ok {
+		return queue
+	}
+	queue := collection.NewDList()
+	l.queues[key] = queue
+	l.keys = append(l.keys, key)
+	return queue
+}
+
+// setCurrent makes the key the next to run with its full weight
+func (l *schedulerLevel) setCurrent(key string) {
+	for i, k := range l.keys {
+		if k == key {
+			l.current = i
+			l.credit = 0
+			return
+		}
+	}
+}
+
+// pop the next task of the current key, moving to the next key when the current key has used its weight or has no
+// more waiting tasks
+func (l *schedulerLevel) pop(weight func(string) int) *internalTask {
+	key := l.keys[l.current]
+	if l.credit <= 0 {
+		l.credit = weight(key)
+	}
+	queue := l.queues[key]
+	data, _ := queue.Remove(queue.Head())
+	l.credit--
+	if 0 == queue.Size() {
+		delete(l.queues, key)
+		l.keys = append(l.keys[:l.current], l.keys[l.current+1:]...)
+		l.credit = 0
+	} else if l.credit <= 0 {
+		l.current++
+	}
+	if l.current >= len(l.keys) {
+		l.current = 0
+	}
+	task, _ := data.(*internalTask)
+	return task
+}
@@ -0,0 +1,119 @@
+package dispatcher
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type scheduledTask struct {
+	name     string
+	key      string
+	priority int
+	executed func(string)
+}
+
+func (task *scheduledTask) Execute() error {
+	if nil != task.executed {
+		task.executed(task.name)
+	}
+	return nil
+}
+
+func (task *scheduledTask) GetKey() string {
+	return task.key
+}
+
+func (task *scheduledTask) GetPriority() int {
+	return task.priority
+}
+
+func newScheduledTask(name string, key string, priority int) *internalTask {
+	return newInternalTask(&scheduledTask{name: name, key: key, priority: priority})
+}
+
+func popAll(s *scheduler) []string {
+	names := []string{}
+	for task, ok := s.Pop(); ok; task, ok = s.Pop() {
+		names = append(names, task.Task.(*scheduledTask).name)
+	}
+	return names
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	assert := assert.New(t)
+	s := newScheduler()
+	s.Push(newScheduledTask("low1", "", 0))
+	s.Push(newScheduledTask("high1", "", 5))
+	s.Push(newScheduledTask("low2", "", 0))
+	s.Push(newScheduledTask("negative", "", -1))
+	s.Push(newScheduledTask("high2", "", 5))
+	assert.Equal(5, s.Size())
+	assert.Equal([]string{"high1", "high2", "low1", "low2", "negative"}, popAll(s))
+	assert.Equal(0, s.Size())
+}
+
+func TestSchedulerFairness(t *testing.T) {
+	assert := assert.New(t)
+	s := newScheduler()
+	for i := 0; i < 4; i++ {
+		s.Push(newScheduledTask(fmt.Sprintf("noisy%d", i), "noisy", 0))
+	}
+	s.Push(newScheduledTask("quiet0", "quiet", 0))
+	s.Push(newScheduledTask("quiet1", "quiet", 0))
+	assert.Equal([]string{"noisy0", "quiet0", "noisy1", "quiet1", "noisy2", "noisy3"}, popAll(s))
+
+	s.SetWeight("heavy", 3)
+	for i := 0; i < 4; i++ {
+		s.Push(newScheduledTask(fmt.Sprintf("heavy%d", i), "heavy", 0))
+		s.Push(newScheduledTask(fmt.Sprintf("light%d", i), "light", 0))
+	}
+	assert.Equal([]string{"heavy0", "heavy1", "heavy2", "light0", "heavy3", "light1", "light2", "light3"},
+		popAll(s))
+}
+
+func TestSchedulerRequeue(t *testing.T) {
+	assert := assert.New(t)
+	s := newScheduler()
+	s.Push(newScheduledTask("a0", "a", 0))
+	s.Push(newScheduledTask("a1", "a", 0))
+	s.Push(newScheduledTask("b0", "b", 0))
+
+	task, ok := s.Pop()
+	assert.True(ok)
+	s.Requeue(task)
+	assert.Equal([]string{"a0", "b0", "a1"}, popAll(s))
+
+	s.Push(newScheduledTask("a0", "a", 0))
+	s.Push(newScheduledTask("b0", "b", 0))
+	task, _ = s.Pop()
+	s.Requeue(task)
+	assert.Equal([]string{"a0", "b0"}, popAll(s))
+}
+
+func TestDispatchPriority(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	var mutex sync.Mutex
+	executed := []string{}
+	done := make(chan bool)
+	record := func(name string) {
+		mutex.Lock()
+		executed = append(executed, name)
+		if 4 == len(executed) {
+			close(done)
+		}
+		mutex.Unlock()
+	}
+	// tasks dispatched before running are scheduled together
+	d.Dispatch(&scheduledTask{name: "low", executed: record})
+	d.Dispatch(&scheduledTask{name: "tenant1", key: "tenant", priority: 1, executed: record})
+	d.Dispatch(&scheduledTask{name: "tenant2", key: "tenant", priority: 1, executed: record})
+	d.Dispatch(&scheduledTask{name: "high", priority: 2, executed: record})
+	d.Run()
+	<-done
+	d.Quit(false)
+	assert.Equal([]string{"high", "tenant1", "tenant2", "low"}, executed)
+}
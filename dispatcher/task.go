@@ -1,10 +1,14 @@
 package dispatcher
 
 import (
+	"context"
+	"math/rand"
 	"time"
 
 	"github.com/Kasita-Inc/gadget/errors"
 	"github.com/Kasita-Inc/gadget/generator"
+	"github.com/Kasita-Inc/gadget/intutil"
+	"github.com/Kasita-Inc/gadget/timeutil"
 )
 
 // Task is the unit of work to be executed by a worker in the pool.
@@ -13,6 +17,55 @@ type Task interface {
 	Execute() error
 }
 
+// ContextTask is a Task that can be cancelled. ExecuteContext is called instead of Execute with a context that is
+// cancelled when the task times out or the dispatcher quits without draining.
+type ContextTask interface {
+	Task
+	// ExecuteContext function on the task on receipt and log the error, return when the context is done.
+	ExecuteContext(ctx context.Context) error
+}
+
+// TimeoutTask is a Task that defines its own timeout instead of DefaultTaskTimeout. A timeout less than zero disables
+// the timeout. The timeout is only enforced for a ContextTask, a Task that cannot take a context holds its worker until
+// it returns.
+type TimeoutTask interface {
+	Task
+	// Timeout for a single execution of the task
+	Timeout() time.Duration
+}
+
+// TaskFunc is a ContextTask that calls itself
+type TaskFunc func(ctx context.Context) error
+
+// Execute the function with a background context
+func (f TaskFunc) Execute() error {
+	return f(context.Background())
+}
+
+// ExecuteContext the function with the passed context
+func (f TaskFunc) ExecuteContext(ctx context.Context) error {
+	return f(ctx)
+}
+
+// taskTimeout returns the timeout of the task, less than or equal to zero when there is none
+func taskTimeout(task Task) time.Duration {
+	if t, ok := task.(TimeoutTask); ok {
+		return t.Timeout()
+	}
+	if _, ok := task.(ContextTask); ok {
+		return DefaultTaskTimeout
+	}
+	return 0
+}
+
+// executeTask calls ExecuteContext for a ContextTask and Execute otherwise
+func executeTask(ctx context.Context, task Task) error {
+	if t, ok := task.(ContextTask); ok {
+		return t.ExecuteContext(ctx)
+	}
+	return task.Execute()
+}
+
 type retryTask struct {
 	base    Task
 	retry   func() bool
@@ -20,28 +73,64 @@ type retryTask struct {
 	period  time.Duration
 }
 
-// NewRetryTask for the passed task that will retry execution up to the amount of retries specified
-// whenever the passed retry function returns true.
+// NewRetryTask for the passed task that will execute it up to the amount of retries specified while it returns an
+// error and the passed retry function returns true, waiting with exponential backoff starting at period between
+// executions. The error of the last execution is returned. The waits hold the worker executing the task, prefer
+// WithRetry for tasks executed by a dispatcher.
 func NewRetryTask(task Task, retry func() bool, retries int, period time.Duration) Task {
 	return &retryTask{base: task, retry: retry, retries: retries, period: period}
 }
 
 func (rt *retryTask) Execute() error {
-	retry := true
-	for i := 0; i < rt.retries && retry; i++ {
-		time.Sleep(rt.period * time.Duration(i*5))
-		rt.base.Execute()
-		retry = rt.retry()
+	return rt.ExecuteContext(context.Background())
+}
+
+func (rt *retryTask) ExecuteContext(ctx context.Context) error {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	var err error
+	for i := 0; i < rt.retries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(timeutil.CalculateBackoff(r, i, rt.period, DefaultRetryCeiling)):
+			}
+		}
+		err = executeTask(ctx, rt.base)
+		if nil == err || !rt.retry() {
+			break
+		}
+	}
+	return err
+}
+
+// Timeout allows every retry of the base task to use its full timeout in addition to the longest waits between them
+func (rt *retryTask) Timeout() time.Duration {
+	timeout := taskTimeout(rt.base)
+	if timeout <= 0 {
+		return timeout
 	}
-	return nil
+	waits := DefaultRetryCeiling * time.Duration(intutil.Maxv(0, rt.retries-1))
+	return timeout*time.Duration(rt.retries) + waits
 }
 
 type internalTask struct {
 	ID        string
 	StartTime string
 	Duration  string
-	Error     errors.TracerError
-	Task      Task
+	// Error returned by the task, a TimeoutError when it timed out or a CancelledError when it was cancelled
+	Error errors.TracerError
+	Task  Task
+	// future is completed with the result of the task when it was dispatched with DispatchWithResult
+	future *future
+	// attempts is the number of times the task has been executed
+	attempts int
+	// firstStart is the start of the first execution of the task
+	firstStart time.Time
+	// retry is called with the error of each execution and returns true when the task will be executed again
+	retry func(it *internalTask, err error) bool
+	// done is called when each execution returns, after the retry is scheduled and the future is completed
+	done func()
 }
 
 func newInternalTask(t Task) *internalTask {
@@ -52,9 +141,52 @@ func newInternalTask(t Task) *internalTask {
 }
 
 func (it *internalTask) Execute() error {
+	return it.ExecuteContext(context.Background())
+}
+
+// ExecuteContext runs the task until it returns. A ContextTask is executed with a context that is done at its timeout
+// and should return as soon as it is, the error it then returns is recorded as a TimeoutError or CancelledError.
+func (it *internalTask) ExecuteContext(ctx context.Context) error {
+	if nil != it.done {
+		defer it.done()
+	}
+	if nil != it.future && 0 == it.attempts && !it.future.start() {
+		// cancelled before it started
+		it.Error = NewCancelledError()
+		return it.Error
+	}
+	timeout := taskTimeout(it.Task)
+	if _, ok := it.Task.(ContextTask); ok && timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	st := time.Now()
 	it.StartTime = st.String()
-	it.Error = errors.Wrap(it.Task.Execute())
-	it.Duration = time.Since(st).String()
+	if 0 == it.attempts {
+		it.firstStart = st
+	}
+	it.attempts++
+	// the worker is held until the task returns so that the pool size limits the tasks that are executing
+	err := executeTask(ctx, it.Task)
+	if nil != err && nil != ctx.Err() {
+		err = it.contextError(ctx, timeout)
+	}
+	it.Error = errors.Wrap(err)
+	elapsed := time.Since(st)
+	it.Duration = elapsed.String()
+	if nil != it.retry && it.retry(it, err) {
+		return it.Error
+	}
+	if nil != it.future {
+		it.future.finish(&Result{StartTime: st, Duration: elapsed, Error: it.Error, Attempts: it.attempts})
+	}
 	return it.Error
 }
+
+func (it *internalTask) contextError(ctx context.Context, timeout time.Duration) errors.TracerError {
+	if context.DeadlineExceeded == ctx.Err() {
+		return NewTimeoutError(timeout)
+	}
+	return NewCancelledError()
+}
@@ -0,0 +1,124 @@
+package dispatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timeoutTask struct {
+	TaskFunc
+	timeout time.Duration
+}
+
+func (task *timeoutTask) Timeout() time.Duration {
+	return task.timeout
+}
+
+func TestTaskTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	// a context task is cancelled at its deadline
+	cancelled := make(chan bool, 1)
+	task := newInternalTask(&timeoutTask{timeout: 10 * time.Millisecond, TaskFunc: func(ctx context.Context) error {
+		<-ctx.Done()
+		cancelled <- true
+		return ctx.Err()
+	}})
+	err := task.Execute()
+	assert.IsType(&TimeoutError{}, err)
+	assert.Equal(err, task.Error)
+	assert.True(<-cancelled)
+
+	// a task that ignores its context runs until it returns
+	task = newInternalTask(&timeoutTask{timeout: 10 * time.Millisecond, TaskFunc: func(ctx context.Context) error {
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	}})
+	start := time.Now()
+	assert.NoError(task.Execute())
+	assert.True(time.Since(start) >= 30*time.Millisecond)
+
+	// a task that cannot take a context has no timeout
+	assert.Equal(time.Duration(0), taskTimeout(&GenericTask{}))
+
+	// a negative timeout disables the timeout
+	task = newInternalTask(&timeoutTask{timeout: -1, TaskFunc: func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		assert.False(ok)
+		return nil
+	}})
+	assert.NoError(task.Execute())
+
+	// tasks use the default timeout
+	task = newInternalTask(TaskFunc(func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		assert.True(ok)
+		assert.True(time.Until(deadline) <= DefaultTaskTimeout)
+		return nil
+	}))
+	assert.NoError(task.Execute())
+}
+
+// blockingTask cannot take a context and returns when it is released
+type blockingTask struct {
+	started chan bool
+	release chan bool
+}
+
+func (task *blockingTask) Execute() error {
+	task.started <- true
+	<-task.release
+	return nil
+}
+
+func (task *blockingTask) Timeout() time.Duration {
+	return time.Millisecond
+}
+
+func TestSlowTaskHoldsWorker(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	d.Run()
+	defer d.Quit(false)
+
+	slow := &blockingTask{started: make(chan bool, 1), release: make(chan bool)}
+	slowResult := d.DispatchWithResult(slow)
+	<-slow.started
+	next := d.DispatchWithResult(&GenericTask{execute: func() error { return nil }})
+
+	// the only worker is busy with the slow task well past its timeout
+	select {
+	case <-next.Done():
+		assert.Fail("task executed while the worker was busy")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(slow.release)
+	result, err := slowResult.Wait(context.Background())
+	assert.NoError(err)
+	assert.NoError(result.Error)
+	result, err = next.Wait(context.Background())
+	assert.NoError(err)
+	assert.NoError(result.Error)
+}
+
+func TestTaskCancelled(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	task := newInternalTask(TaskFunc(func(ctx context.Context) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	assert.IsType(&CancelledError{}, task.ExecuteContext(ctx))
+}
+
+func TestRetryTaskTimeout(t *testing.T) {
+	assert := assert.New(t)
+	task := NewRetryTask(&timeoutTask{timeout: time.Second}, func() bool { return true }, 3, time.Millisecond)
+	assert.Equal(3*time.Second+2*DefaultRetryCeiling, taskTimeout(task))
+	task = NewRetryTask(&timeoutTask{timeout: -1}, func() bool { return true }, 3, time.Millisecond)
+	assert.Equal(time.Duration(-1), taskTimeout(task))
+}
@@ -0,0 +1,193 @@
+package dispatcher
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/Kasita-Inc/gadget/timeutil"
+)
+
+// Recurring is the handle of a task dispatched on a schedule
+type Recurring interface {
+	// Next time the task will be dispatched, the zero time when it has stopped
+	Next() time.Time
+	// Stop dispatching the task, executions that were already dispatched are not affected
+	Stop()
+}
+
+// timer is a task waiting in the timers until it is due
+type timer struct {
+	at   time.Time
+	seq  uint64
+	task *internalTask
+	// schedule of a recurring task, nil for a task that is dispatched once
+	schedule timeutil.Schedule
+	// recurring tasks are dispatched as a new internalTask each time
+	base   Task
+	index  int
+	timers *timers
+}
+
+func (t *timer) Next() time.Time {
+	t.timers.mutex.Lock()
+	defer t.timers.mutex.Unlock()
+	if t.index < 0 {
+		return time.Time{}
+	}
+	return t.at
+}
+
+func (t *timer) Stop() {
+	t.timers.remove(t)
+}
+
+// timerHeap orders timers by when they are due and then by when they were added
+type timerHeap []*timer
+
+func (h timerHeap) Len() int { return len(h) }
+
+func (h timerHeap) Less(i, j int) bool {
+	if h[i].at.Equal(h[j].at) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].at.Before(h[j].at)
+}
+
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x interface{}) {
+	t := x.(*timer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *timerHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	t.index = -1
+	*h = old[:len(old)-1]
+	return t
+}
+
+// timers holds the delayed and recurring tasks of the dispatcher in a heap so that a single timer in the run loop
+// dispatches them when they are due
+type timers struct {
+	mutex sync.Mutex
+	heap  timerHeap
+	seq   uint64
+	// number of timers that are dispatched once, a drain waits for them
+	once int
+	// signals that the earliest timer changed
+	wake chan bool
+}
+
+func newTimers() *timers {
+	return &timers{wake: make(chan bool, 1)}
+}
+
+// Once dispatches the task at the time
+func (ts *timers) Once(at time.Time, task *internalTask) *timer {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.once++
+	return ts.add(&timer{at: at, task: task})
+}
+
+// Every dispatches the task on the schedule, false when the schedule never runs
+func (ts *timers) Every(schedule timeutil.Schedule, task Task) (*timer, bool) {
+	at := schedule.Next(time.Now())
+	if at.IsZero() {
+		return &timer{index: -1, timers: ts}, false
+	}
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	return ts.add(&timer{at: at, schedule: schedule, base: task}), true
+}
+
+func (ts *timers) add(t *timer) *timer {
+	ts.seq++
+	t.seq = ts.seq
+	t.timers = ts
+	heap.Push(&ts.heap, t)
+	if 0 == t.index {
+		sendNonBlocking(true, ts.wake)
+	}
+	return t
+}
+
+func (ts *timers) remove(t *timer) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	if t.index < 0 {
+		return
+	}
+	heap.Remove(&ts.heap, t.index)
+	if nil == t.schedule {
+		ts.once--
+	}
+	sendNonBlocking(true, ts.wake)
+}
+
+// Pending is the number of tasks waiting to be dispatched once
+func (ts *timers) Pending() int {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	return ts.once
+}
+
+// Next is the time the earliest timer is due, false when there are none
+func (ts *timers) Next() (time.Time, bool) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	if len(ts.heap) == 0 {
+		return time.Time{}, false
+	}
+	return ts.heap[0].at, true
+}
+
+// Due removes the timers that are due at now and returns their tasks. Recurring timers are moved to their next time
+// and their task is only created when recurring is true, so that they do not fire while the dispatcher drains.
+func (ts *timers) Due(now time.Time, recurring bool, create func(Task) *internalTask) []*internalTask {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	tasks := []*internalTask{}
+	for len(ts.heap) > 0 && !ts.heap[0].at.After(now) {
+		t := ts.heap[0]
+		if nil == t.schedule {
+			heap.Pop(&ts.heap)
+			ts.once--
+			tasks = append(tasks, t.task)
+			continue
+		}
+		if recurring {
+			tasks = append(tasks, create(t.base))
+		}
+		// runs that were missed while the dispatcher was stopped are skipped
+		t.at = t.schedule.Next(now)
+		if t.at.IsZero() {
+			heap.Pop(&ts.heap)
+		} else {
+			heap.Fix(&ts.heap, 0)
+		}
+	}
+	return tasks
+}
+
+// arm the timer of the run loop for the earliest timer
+func (ts *timers) arm(clock *time.Timer) {
+	if !clock.Stop() {
+		select {
+		case <-clock.C:
+		default:
+		}
+	}
+	if next, ok := ts.Next(); ok {
+		clock.Reset(time.Until(next))
+	}
+}
@@ -0,0 +1,112 @@
+package dispatcher
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// intervalSchedule is due every interval
+type intervalSchedule time.Duration
+
+func (s intervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(s))
+}
+
+func TestDispatchAfter(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	d.Run()
+	defer d.Quit(false)
+
+	order := make(chan int, 3)
+	start := time.Now()
+	later := d.DispatchAfter(40*time.Millisecond, &GenericTask{execute: func() error {
+		order <- 2
+		return nil
+	}})
+	sooner := d.DispatchAt(start.Add(20*time.Millisecond), &GenericTask{execute: func() error {
+		order <- 1
+		return nil
+	}})
+	d.Dispatch(&GenericTask{execute: func() error {
+		order <- 0
+		return nil
+	}})
+	result, err := later.Wait(context.Background())
+	assert.NoError(err)
+	assert.NoError(result.Error)
+	assert.True(result.StartTime.Sub(start) >= 40*time.Millisecond)
+	_, err = sooner.Wait(context.Background())
+	assert.NoError(err)
+	assert.Equal(0, <-order)
+	assert.Equal(1, <-order)
+	assert.Equal(2, <-order)
+}
+
+func TestDispatchAfterCancel(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	d.Run()
+	defer d.Quit(false)
+
+	var executed int32
+	future := d.DispatchAfter(20*time.Millisecond, &GenericTask{execute: func() error {
+		atomic.AddInt32(&executed, 1)
+		return nil
+	}})
+	assert.Equal(1, d.(*dispatcher).timers.Pending())
+	assert.True(future.Cancel())
+	assert.Equal(0, d.(*dispatcher).timers.Pending())
+	time.Sleep(40 * time.Millisecond)
+	assert.Equal(int32(0), atomic.LoadInt32(&executed))
+}
+
+func TestDispatchSchedule(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	d.Run()
+
+	runs := make(chan bool, 10)
+	recurring := d.DispatchSchedule(intervalSchedule(10*time.Millisecond), &GenericTask{execute: func() error {
+		runs <- true
+		return nil
+	}})
+	assert.False(recurring.Next().IsZero())
+	for i := 0; i < 3; i++ {
+		assert.True(<-runs)
+	}
+	recurring.Stop()
+	assert.True(recurring.Next().IsZero())
+	// recurring tasks do not hold up a drain
+	d.Quit(true)
+
+	_, err := d.DispatchCron("not cron", &GenericTask{})
+	assert.Error(err)
+	recurring, err = d.DispatchCron("@hourly", &GenericTask{})
+	assert.NoError(err)
+	assert.Equal(0, recurring.Next().Minute())
+	recurring.Stop()
+}
+
+func TestDrainWaitsForDelayedTasks(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDispatcher(10, 1, 1)
+	d.Run()
+	d.DispatchAfter(30*time.Millisecond, &GenericTask{execute: func() error { return nil }})
+	d.DispatchSchedule(intervalSchedule(time.Millisecond), &GenericTask{execute: func() error { return nil }})
+	start := time.Now()
+	d.Quit(true)
+	assert.True(time.Since(start) >= 25*time.Millisecond)
+	assert.Equal(0, d.(*dispatcher).timers.Pending())
+
+	// a delayed task is kept when quitting without draining
+	d.Run()
+	future := d.DispatchAfter(time.Hour, &GenericTask{})
+	d.Quit(false)
+	assert.Equal(1, d.(*dispatcher).timers.Pending())
+	assert.True(future.Cancel())
+}
@@ -1,6 +1,7 @@
 package dispatcher
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -8,8 +9,8 @@ import (
 	"github.com/Kasita-Inc/gadget/log"
 )
 
-// DefaultTaskTimeout is the timeout for tasks that do not define their own
-// timeout.
+// DefaultTaskTimeout is the timeout for a ContextTask that does not define its
+// own timeout by implementing TimeoutTask.
 const DefaultTaskTimeout = 10 * time.Second
 
 type dummy struct{}
@@ -45,14 +46,22 @@ type worker struct {
 	exited chan bool
 	// channel where we put completed tasks.
 	complete chan<- *internalTask
+	// the context tasks are executed with, cancelling it cancels the current task
+	ctx context.Context
 }
 
 // NewWorker for the passed worker pool.
 func NewWorker(pool chan Worker, complete chan<- *internalTask) Worker {
+	return newWorker(context.Background(), pool, complete)
+}
+
+// newWorker for the passed worker pool that executes tasks with the passed context
+func newWorker(ctx context.Context, pool chan Worker, complete chan<- *internalTask) Worker {
 	worker := &worker{
 		pool:     pool,
 		running:  0,
 		complete: complete,
+		ctx:      ctx,
 	}
 	return worker
 }
@@ -98,7 +107,7 @@ func (w *worker) run(loaded chan bool) {
 		// never succeed
 		select {
 		case task := <-w.tasks:
-			log.Error(task.Execute())
+			log.Error(task.ExecuteContext(w.ctx))
 			w.completeTask(task)
 			if exit {
 				w.exited <- true
@@ -0,0 +1,24 @@
+package timeutil
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// CalculateBackoff returns a duration for exponential backoff
+func CalculateBackoff(r *rand.Rand, attempt int, minimumCycle time.Duration, maxCycle time.Duration) time.Duration {
+	minMS := uint(minimumCycle.Seconds() * 1000)
+	// if min cycle is 1 millisecond or smaller bring it over 1 to make the exponentiation work
+	if minMS <= 1 {
+		minMS = 2
+	}
+	maxMS := maxCycle.Seconds() * 1000
+	full := math.Min(math.Pow(float64(minMS), float64(attempt)), maxMS)
+	// exponentiation will be 90% of our calculated value with maxCycle as a ceiling
+	exp := full * 0.9
+	// r.Float64() is in [0.0, 1.0]
+	jitter := r.Float64() * float64(full*0.1)
+	// exponentiation plus jitter
+	return time.Duration(exp+jitter) * time.Millisecond
+}
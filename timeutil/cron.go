@@ -0,0 +1,206 @@
+package timeutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kasita-Inc/gadget/errors"
+)
+
+// Schedule of a recurring job
+type Schedule interface {
+	// Next time the job runs after the passed time, the zero time when it never runs again
+	Next(after time.Time) time.Time
+}
+
+// InvalidCronError is returned when a cron expression cannot be parsed
+type InvalidCronError struct {
+	Spec   string
+	Reason string
+	trace  []string
+}
+
+func (err *InvalidCronError) Error() string {
+	return fmt.Sprintf("invalid cron expression '%s': %s", err.Spec, err.Reason)
+}
+
+// Trace returns the stack trace for the error
+func (err *InvalidCronError) Trace() []string {
+	return err.trace
+}
+
+// NewInvalidCronError instantiates an InvalidCronError with a stack trace
+func NewInvalidCronError(spec string, format string, args ...interface{}) errors.TracerError {
+	return &InvalidCronError{Spec: spec, Reason: fmt.Sprintf(format, args...), trace: errors.GetStackTrace()}
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+// cronSchedule holds the allowed values of each field as bits
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// a day matches either day field when both are restricted, as in cron
+	domAny, dowAny bool
+	location       *time.Location
+}
+
+// everySchedule runs at a fixed interval
+type everySchedule struct {
+	every time.Duration
+}
+
+func (s *everySchedule) Next(after time.Time) time.Time {
+	return after.Add(s.every)
+}
+
+// ParseCron parses a standard five field cron expression, minute hour day-of-month month day-of-week, in the local
+// time zone. Fields support *, lists, ranges and steps such as "*/15 9-17 * * 1-5". The descriptors @yearly,
+// @monthly, @weekly, @daily, @hourly and "@every <duration>" are also supported.
+func ParseCron(spec string) (Schedule, errors.TracerError) {
+	return ParseCronIn(spec, time.Local)
+}
+
+// ParseCronIn parses a cron expression that is evaluated in the passed location
+func ParseCronIn(spec string, location *time.Location) (Schedule, errors.TracerError) {
+	expression := strings.TrimSpace(spec)
+	if strings.HasPrefix(expression, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expression, "@every ")))
+		if nil != err {
+			return nil, NewInvalidCronError(spec, err.Error())
+		}
+		if every <= 0 {
+			return nil, NewInvalidCronError(spec, "interval must be greater than zero")
+		}
+		return &everySchedule{every: every}, nil
+	}
+	if descriptor, ok := cronDescriptors[expression]; ok {
+		expression = descriptor
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return nil, NewInvalidCronError(spec, "expected %d fields but found %d", len(cronFields), len(fields))
+	}
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		var err errors.TracerError
+		if bits[i], err = parseCronField(spec, field, cronFields[i]); nil != err {
+			return nil, err
+		}
+	}
+	// sunday is both 0 and 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &cronSchedule{
+		minute:   bits[0],
+		hour:     bits[1],
+		dom:      bits[2],
+		month:    bits[3],
+		dow:      bits[4],
+		domAny:   "*" == fields[2] || strings.HasPrefix(fields[2], "*/"),
+		dowAny:   "*" == fields[4] || strings.HasPrefix(fields[4], "*/"),
+		location: location,
+	}, nil
+}
+
+// parseCronField returns the allowed values of a comma separated field as bits
+func parseCronField(spec string, field string, bounds cronField) (uint64, errors.TracerError) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); nil != err || step <= 0 {
+				return 0, NewInvalidCronError(spec, "invalid step in %s '%s'", bounds.name, part)
+			}
+			part = part[:i]
+		}
+		start, end := bounds.min, bounds.max
+		if "*" != part {
+			values := strings.SplitN(part, "-", 2)
+			var err error
+			if start, err = strconv.Atoi(values[0]); nil != err {
+				return 0, NewInvalidCronError(spec, "invalid %s '%s'", bounds.name, part)
+			}
+			end = start
+			if len(values) == 2 {
+				if end, err = strconv.Atoi(values[1]); nil != err {
+					return 0, NewInvalidCronError(spec, "invalid %s '%s'", bounds.name, part)
+				}
+			} else if step > 1 {
+				// a single value with a step runs from the value to the end of the range
+				end = bounds.max
+			}
+		}
+		if start < bounds.min || end > bounds.max || start > end {
+			return 0, NewInvalidCronError(spec, "%s '%s' is out of range %d-%d", bounds.name, part, bounds.min,
+				bounds.max)
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	t := after.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	// a schedule that can never run, such as the 30th of February, gives up after a few years
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !s.match(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.match(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.match(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *cronSchedule) match(bits uint64, value int) bool {
+	return bits&(1<<uint(value)) != 0
+}
+
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dom := s.match(s.dom, t.Day())
+	dow := s.match(s.dow, int(t.Weekday()))
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,51 @@
+package timeutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	assert := assert.New(t)
+	// a wednesday
+	start := time.Date(2020, time.January, 1, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{spec: "* * * * *", expected: time.Date(2020, time.January, 1, 10, 8, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", expected: time.Date(2020, time.January, 1, 10, 15, 0, 0, time.UTC)},
+		{spec: "5,40 9-17 * * *", expected: time.Date(2020, time.January, 1, 10, 40, 0, 0, time.UTC)},
+		{spec: "0 9 * * 1-5", expected: time.Date(2020, time.January, 2, 9, 0, 0, 0, time.UTC)},
+		{spec: "30 2 * * 7", expected: time.Date(2020, time.January, 5, 2, 30, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", expected: time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// either day field matches when both are restricted
+		{spec: "0 0 15 * 5", expected: time.Date(2020, time.January, 3, 0, 0, 0, 0, time.UTC)},
+		{spec: "@daily", expected: time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", expected: time.Date(2020, time.January, 1, 11, 0, 0, 0, time.UTC)},
+		{spec: "@monthly", expected: time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@every 90s", expected: start.Add(90 * time.Second)},
+	}
+	for _, test := range tests {
+		schedule, err := ParseCronIn(test.spec, time.UTC)
+		if assert.NoError(err, test.spec) {
+			assert.Equal(test.expected, schedule.Next(start), test.spec)
+		}
+	}
+
+	// never due
+	schedule, err := ParseCronIn("0 0 30 2 *", time.UTC)
+	assert.NoError(err)
+	assert.True(schedule.Next(start).IsZero())
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	assert := assert.New(t)
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *", "@every", "@every -1s", "@every soon"} {
+		_, err := ParseCron(spec)
+		assert.IsType(&InvalidCronError{}, err, spec)
+	}
+}